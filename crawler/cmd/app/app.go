@@ -40,13 +40,33 @@ func main() {
 	root := filepath.Join(wd, "tests")
 	fmt.Println(root)
 
+	fileSystem := fs.NewArchiveFileSystem(fs.NewOsFileSystem(), fs.ArchiveLimits{}) // archives in root are crawled as directories
+	defer func() {
+		_ = fileSystem.Close()
+	}()
+
 	c := crawler.New[TestType, TestAccumulator]()
-	result, err := c.Collect(ctx, fs.NewOsFileSystem(), root, crawler.Configuration{
+	job := c.Start(ctx, fileSystem, root, crawler.Configuration{
 		SearchWorkers:      10,
 		FileWorkers:        10,
 		AccumulatorWorkers: 10,
 	}, accum, combiner)
 
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for running := true; running; {
+		select {
+		case <-ticker.C:
+		case <-job.Done():
+			running = false
+		}
+		p := job.Progress()
+		fmt.Printf("\rdirs: %d, files: %d/%d, accumulated: %d/%d, errors: %d",
+			p.DirsVisited, p.FilesDecoded, p.FilesFound, p.Accumulated, p.Values, p.Errors)
+	}
+	fmt.Println()
+
+	result, err := job.Wait()
 	if err != nil {
 		panic(err)
 	}
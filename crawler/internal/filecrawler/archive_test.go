@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crawler/internal/fs"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func zipOf(t *testing.T, files map[string]string) []byte {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func tarGzOf(t *testing.T, files map[string]string) []byte {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	w := tar.NewWriter(gz)
+	for name, data := range files {
+		require.NoError(t, w.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}))
+		_, err := w.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestCollectArchives(t *testing.T) {
+	root := writeFiles(t, map[string][]byte{
+		"a.json": []byte(`{"data": 1}`),
+		"b/bundle.zip": zipOf(t, map[string]string{
+			"x/c.json":      `{"data": 2}`,
+			"x/d.ndjson":    "{\"data\": 4}\n{\"data\": 8}",
+			"x/broken.json": `{`,
+		}),
+		"e.tar.gz": tarGzOf(t, map[string]string{
+			"f.json":   `{"data": 16}`,
+			"g/h.json": `{"data": 32}`,
+		}),
+	})
+	fileSystem := fs.NewArchiveFileSystem(fs.NewOsFileSystem(), fs.ArchiveLimits{})
+	t.Cleanup(func() {
+		require.NoError(t, fileSystem.Close())
+	})
+
+	job := &Job[TestAccumulator]{}
+	result, err := New[TestType, TestAccumulator]().Collect(context.Background(), fileSystem, root, Configuration{
+		SearchWorkers:      2,
+		FileWorkers:        2,
+		AccumulatorWorkers: 2,
+		ErrorPolicy:        SkipErrors,
+		Exclude:            []string{"b/bundle.zip/x/*.ndjson"}, // patterns see entries of archives as files
+		Observer:           job.count,
+	}, sum, sumCombiner)
+	require.EqualValues(t, 1+2+16+32, result.Sum)
+	require.EqualValues(t, 5, job.Progress().FilesFound)
+
+	var fileErrors FileErrors
+	require.True(t, errors.As(err, &fileErrors))
+	require.Len(t, fileErrors, 1)
+	require.Equal(t, filepath.Join(root, "b", "bundle.zip", "x", "broken.json"), fileErrors[0].Path)
+	require.Equal(t, StageDecode, fileErrors[0].Stage)
+}
+
+func TestCollectArchiveBomb(t *testing.T) {
+	root := writeFiles(t, map[string][]byte{
+		"a.json":   []byte(`{"data": 1}`),
+		"bomb.zip": zipOf(t, map[string]string{"b.json": `{"data": 2}`, "c.json": `{"data": 4}`}),
+	})
+	fileSystem := fs.NewArchiveFileSystem(fs.NewOsFileSystem(), fs.ArchiveLimits{MaxEntries: 1})
+
+	result, err := New[TestType, TestAccumulator]().Collect(context.Background(), fileSystem, root, Configuration{
+		SearchWorkers:      1,
+		FileWorkers:        1,
+		AccumulatorWorkers: 1,
+		ErrorPolicy:        SkipErrors,
+	}, sum, sumCombiner)
+	require.EqualValues(t, 1, result.Sum)
+	require.ErrorIs(t, err, fs.ErrTooManyEntries)
+	require.ErrorContains(t, err, "bomb.zip")
+	require.NoError(t, fileSystem.Close())
+}
@@ -0,0 +1,66 @@
+package crawler
+
+import (
+	"sync"
+)
+
+// The budget limits files in flight: files, which are open and decoded.
+// It counts files and their sizes, zero limits mean no limit. It is shared by workers of the single run, nil budget
+// has no limits
+type budget struct {
+	maxFiles int
+	maxBytes int64
+
+	mu    sync.Mutex
+	cond  *sync.Cond // signalled, when files leave the budget
+	files int
+	bytes int64
+}
+
+// Factory of budget from the configuration, it returns nil, if the configuration has no limits
+func newBudget(conf *Configuration) *budget {
+	if conf.MaxInFlightFiles <= 0 && conf.MaxBytesInFlight <= 0 {
+		return nil
+	}
+	b := &budget{maxFiles: conf.MaxInFlightFiles, maxBytes: conf.MaxBytesInFlight}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Function reports whether sizes of files must be known
+func (b *budget) needsSize() bool {
+	return b != nil && b.maxBytes > 0
+}
+
+// Function waits, until the file of the given size fits into the budget, and takes it. The file larger than
+// the limit of bytes is let in alone, so that it doesn't wait forever. It returns bytes taken by the file
+func (b *budget) acquire(size int64) int64 {
+	if b == nil {
+		return 0
+	}
+	if b.maxBytes > 0 {
+		size = min(size, b.maxBytes)
+	} else {
+		size = 0 // bytes aren't counted
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for (b.maxFiles > 0 && b.files >= b.maxFiles) || (b.maxBytes > 0 && b.bytes+size > b.maxBytes) {
+		b.cond.Wait()
+	}
+	b.files++
+	b.bytes += size
+	return size
+}
+
+// Function returns the file taking the given bytes to the budget
+func (b *budget) release(size int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.files--
+	b.bytes -= size
+	b.cond.Broadcast()
+}
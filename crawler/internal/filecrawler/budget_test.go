@@ -0,0 +1,288 @@
+package crawler
+
+import (
+	"context"
+	"crawler/internal/fs"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// File system, which counts files in flight: they enter on opening and leave, when their value is accumulated
+type inFlightFileSystem struct {
+	fs.FileSystem
+
+	mu       sync.Mutex
+	inFlight int
+	maximum  int
+}
+
+func (f *inFlightFileSystem) Open(name string) (fs.File, error) {
+	f.mu.Lock()
+	f.inFlight++
+	f.maximum = max(f.maximum, f.inFlight)
+	f.mu.Unlock()
+	return f.FileSystem.Open(name)
+}
+
+// Function collects files of the tree with slow accumulator and returns the sum and the maximum of files in flight
+func collectInFlight(t *testing.T, tree fs.FileSystem, conf Configuration) (int64, int) {
+	fileSystem := &inFlightFileSystem{FileSystem: tree}
+	conf.SearchWorkers, conf.FileWorkers, conf.AccumulatorWorkers = 2, 10, 1
+	result, err := New[TestType, TestAccumulator]().Collect(context.Background(), fileSystem, "root", conf,
+		func(current TestType, accum TestAccumulator) TestAccumulator {
+			time.Sleep(time.Millisecond)
+			fileSystem.mu.Lock()
+			fileSystem.inFlight--
+			fileSystem.mu.Unlock()
+			return sum(current, accum)
+		}, sumCombiner)
+	require.NoError(t, err)
+	return result.Sum, fileSystem.maximum
+}
+
+func TestMaxInFlightFiles(t *testing.T) {
+	tree := fs.NewMemFileSystem()
+	for i := range 100 {
+		require.NoError(t, tree.WriteFile(path.Join("root", strconv.Itoa(i%4), strconv.Itoa(i)+".json"), []byte(`{"data": 1}`)))
+	}
+
+	total, unlimited := collectInFlight(t, tree, Configuration{})
+	require.EqualValues(t, 100, total)
+	require.Greater(t, unlimited, 3) // file workers decode files ahead of the slow accumulator
+
+	total, limited := collectInFlight(t, tree, Configuration{MaxInFlightFiles: 2})
+	require.EqualValues(t, 100, total)
+	require.LessOrEqual(t, limited, 2+1) // the accumulator may lag behind the release by one value
+}
+
+func TestMaxBytesInFlight(t *testing.T) {
+	tree := fs.NewMemFileSystem()
+	for i := range 50 {
+		require.NoError(t, tree.WriteFile(path.Join("root", strconv.Itoa(i)+".json"), []byte(`{"data": 1}`))) // 11 bytes
+	}
+	require.NoError(t, tree.WriteFile("root/large.json", []byte(`{"data": 2}`+strings.Repeat(" ", 100))))
+
+	total, limited := collectInFlight(t, tree, Configuration{MaxBytesInFlight: 25})
+	require.EqualValues(t, 52, total) // the file larger than the limit is crawled too
+	require.LessOrEqual(t, limited, 2+1)
+}
+
+// File system, which counts entries in flight: they enter, when ReadDir lists them, and leave,
+// when the file is opened or the directory is read
+type listedFileSystem struct {
+	fs.FileSystem
+
+	mu       sync.Mutex
+	inFlight int
+	maximum  int
+}
+
+func (l *listedFileSystem) track(delta int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight += delta
+	l.maximum = max(l.maximum, l.inFlight)
+}
+
+func (l *listedFileSystem) Open(name string) (fs.File, error) {
+	l.track(-1)
+	return l.FileSystem.Open(name)
+}
+
+func (l *listedFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	entries, err := l.FileSystem.ReadDir(name)
+	l.track(len(entries) - 1)
+	return entries, err
+}
+
+func TestWideTreeEntriesInFlight(t *testing.T) {
+	const fanout, depth, workers = 10, 3, 2
+	fileSystem := &listedFileSystem{FileSystem: newWideFileSystem(fanout, depth), inFlight: 1} // root is in flight
+	result, err := New[TestType, TestAccumulator]().Collect(context.Background(), fileSystem, "root", Configuration{
+		SearchWorkers:      workers,
+		FileWorkers:        workers,
+		AccumulatorWorkers: 1,
+		MaxInFlightFiles:   workers,
+	}, sum, sumCombiner)
+	require.NoError(t, err)
+	require.EqualValues(t, 10_000, result.Sum)
+	require.Zero(t, fileSystem.inFlight)
+	// the tree has 11110 entries, but every search worker holds only the rest of entries of directories
+	// on its path from root, and every file worker holds the single found file waiting for the budget
+	require.LessOrEqual(t, fileSystem.maximum, workers*(depth+1)*fanout+workers)
+}
+
+// Synthetic read-only tree, which doesn't keep its entries in memory: every directory of depth less than
+// depth has fanout subdirectories and every directory of depth depth has fanout files with the single value
+type wideFileSystem struct {
+	depth       int
+	dirs, files []os.DirEntry // entries are the same in all directories
+}
+
+// Synthetic file or directory of wideFileSystem
+type wideInfo struct {
+	name string
+	dir  bool
+}
+
+func newWideFileSystem(fanout int, depth int) *wideFileSystem {
+	w := &wideFileSystem{depth: depth}
+	for i := range fanout {
+		w.dirs = append(w.dirs, iofs.FileInfoToDirEntry(wideInfo{name: strconv.Itoa(i), dir: true}))
+		w.files = append(w.files, iofs.FileInfoToDirEntry(wideInfo{name: strconv.Itoa(i) + ".json"}))
+	}
+	return w
+}
+
+func (w *wideFileSystem) Open(string) (fs.File, error) {
+	return io.NopCloser(strings.NewReader(`{"data": 1}`)), nil
+}
+
+func (w *wideFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	if strings.Count(name, "/") < w.depth {
+		return w.dirs, nil
+	}
+	return w.files, nil
+}
+
+func (w *wideFileSystem) Stat(name string) (os.FileInfo, error) {
+	return wideInfo{name: path.Base(name), dir: !strings.HasSuffix(name, ".json")}, nil
+}
+
+func (w *wideFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return w.Stat(name)
+}
+
+func (w *wideFileSystem) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+func (i wideInfo) Name() string       { return i.name }
+func (i wideInfo) Size() int64        { return 11 }
+func (i wideInfo) ModTime() time.Time { return time.Time{} }
+func (i wideInfo) IsDir() bool        { return i.dir }
+func (i wideInfo) Sys() any           { return nil }
+
+func (i wideInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// BenchmarkWideTree crawls synthetic trees, which grow by the factor of 10, and reports the peak of the heap.
+// The peak stays flat, because neither layers of directories nor decoded files are collected in memory.
+func BenchmarkWideTree(b *testing.B) {
+	for _, depth := range []int{2, 3, 4, 5} {
+		fileSystem := newWideFileSystem(10, depth)
+		files := 1
+		for range depth + 1 {
+			files *= 10
+		}
+		b.Run("files="+strconv.Itoa(files), func(b *testing.B) {
+			var peak uint64
+			for range b.N {
+				runtime.GC()
+				var before runtime.MemStats
+				runtime.ReadMemStats(&before)
+
+				done := make(chan struct{})
+				sampled := make(chan uint64)
+				go func() { // samples the heap, while crawling runs
+					var stats runtime.MemStats
+					var maximum uint64
+					for {
+						runtime.ReadMemStats(&stats)
+						maximum = max(maximum, stats.HeapInuse)
+						select {
+						case <-done:
+							sampled <- maximum
+							return
+						case <-time.After(time.Millisecond):
+						}
+					}
+				}()
+
+				result, err := New[TestType, TestAccumulator]().Collect(context.Background(), fileSystem, "root", Configuration{
+					SearchWorkers:      8,
+					FileWorkers:        8,
+					AccumulatorWorkers: 8,
+					MaxInFlightFiles:   64,
+				}, sum, sumCombiner)
+				close(done)
+				maximum := <-sampled
+				peak = max(peak, maximum-min(before.HeapInuse, maximum)) // growth of the heap during crawling
+				require.NoError(b, err)
+				require.EqualValues(b, files, result.Sum)
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+		})
+	}
+}
+
+// File system, whose files give their first line and then wait, until it is accumulated
+type gatedFileSystem struct {
+	fs.FileSystem
+	accumulated chan struct{}
+}
+
+// File of gatedFileSystem
+type gatedFile struct {
+	fs.File
+	first       []byte
+	accumulated chan struct{}
+}
+
+func (f *gatedFileSystem) Open(name string) (fs.File, error) {
+	file, err := f.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &gatedFile{File: file, first: []byte("{\"data\": 1}\n"), accumulated: f.accumulated}, nil
+}
+
+func (f *gatedFile) Read(p []byte) (int, error) {
+	if f.first != nil {
+		n := copy(p, f.first)
+		f.first = f.first[n:]
+		if len(f.first) == 0 {
+			f.first = nil
+		}
+		return n, nil
+	}
+	select {
+	case <-f.accumulated:
+		return f.File.Read(p)
+	case <-time.After(5 * time.Second):
+		return 0, io.ErrNoProgress // the whole file has been read before its first value has been accumulated
+	}
+}
+
+func TestValuesStreamedWhileDecoding(t *testing.T) {
+	tree := fs.NewMemFileSystem()
+	require.NoError(t, tree.WriteFile("root/a.ndjson", []byte("{\"data\": 2}\n{\"data\": 4}")))
+	fileSystem := &gatedFileSystem{FileSystem: tree, accumulated: make(chan struct{})}
+
+	once := sync.Once{}
+	result, err := New[TestType, TestAccumulator]().Collect(context.Background(), fileSystem, "root", Configuration{
+		SearchWorkers:      1,
+		FileWorkers:        1,
+		AccumulatorWorkers: 1,
+		MaxInFlightFiles:   1,
+	}, func(current TestType, accum TestAccumulator) TestAccumulator {
+		once.Do(func() { close(fileSystem.accumulated) })
+		return sum(current, accum)
+	}, sumCombiner)
+	require.NoError(t, err)
+	require.EqualValues(t, 1+2+4, result.Sum) // the gated first line is followed by the contents of the file
+}
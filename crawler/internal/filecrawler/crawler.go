@@ -4,8 +4,12 @@ import (
 	"context"
 	"crawler/internal/fs"
 	"crawler/internal/workerpool"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 // Configuration holds the configuration for the crawler, specifying the number of workers for
@@ -16,6 +20,55 @@ type Configuration struct {
 	SearchWorkers      int // Number of workers responsible for searching files.
 	FileWorkers        int // Number of workers for processing individual files.
 	AccumulatorWorkers int // Number of workers for accumulating results.
+
+	// Decoders of files by lower-case extensions with the dot, for example ".csv".
+	// Nil means DefaultDecoders.
+	Decoders map[string]Decoder
+	// Decoder of files, whose extensions aren't found in Decoders. Nil means the JSON decoder,
+	// NewSniffDecoder can be set to guess format by the contents.
+	Decoder Decoder
+
+	// Glob patterns of files to crawl, empty means all files. Pattern without slashes is matched
+	// against the name of the file, otherwise against the slash separated path relative to root,
+	// where "**" matches any count of directories. Other syntax is the one of path.Match.
+	Include []string
+	// Glob patterns of files and directories to skip, excluded directories aren't descended.
+	// Syntax is the same as in Include.
+	Exclude []string
+	// Maximum depth of crawled files, files of root have depth 1. Zero means no limit.
+	MaxDepth int
+	// Whether files and directories, whose names start with '.', are skipped.
+	SkipHidden bool
+	// Treatment of symbolic links, SymlinksAsEntries by default.
+	Symlinks SymlinkPolicy
+	// Maximum size of crawled files in bytes, larger files are skipped. Zero means no limit.
+	MaxFileSize int64
+
+	// Reaction to errors of files and directories, FailFast by default.
+	ErrorPolicy ErrorPolicy
+	// Count of errors, which stops crawling under FailAfterErrors policy. Values less than one mean one.
+	MaxErrors int
+
+	// Hook, which receives events of crawling, nil means no hook.
+	Observer Observer
+
+	// Maximum count of files in flight: files, which are open and decoded. Decoded values are passed
+	// to accumulators one by one, so it bounds open files and buffers of decoders independently of FileWorkers.
+	// Zero means that only FileWorkers limits them.
+	MaxInFlightFiles int
+	// Maximum total size in bytes of files in flight, sizes are taken by Stat of the file system.
+	// A larger file is decoded alone. Zero means no limit. Neither limit covers entries of directories:
+	// ReadDir of the file system lists the whole directory, so a single very wide directory is held
+	// in memory as a whole, while it is searched.
+	MaxBytesInFlight int64
+}
+
+// Function returns decoder of the file by its extension
+func (c *Configuration) decoderFor(path string) Decoder {
+	if d, ok := c.Decoders[strings.ToLower(filepath.Ext(path))]; ok {
+		return d
+	}
+	return c.Decoder
 }
 
 // Combiner is a function type that defines how to combine two values of type R into a single
@@ -31,8 +84,9 @@ type Combiner[R any] func(current R, accum R) R
 
 // Crawler represents a concurrent crawler implementing a map-reduce model with multiple workers
 // to manage file processing, transformation, and accumulation tasks. The crawler is designed to
-// handle large sets of files efficiently, assuming that all files can fit into memory
-// simultaneously.
+// handle large sets of files efficiently: files are streamed through the pipeline, so memory is
+// bounded by the counts of workers, MaxInFlightFiles and MaxBytesInFlight of the Configuration
+// and by the count of entries of the widest directory rather than by the size of the tree.
 type Crawler[T, R any] interface {
 	// Collect performs the full crawling operation, coordinating with the file system
 	// and worker pool to process files and accumulate results. The result type R is assumed
@@ -48,10 +102,15 @@ type Crawler[T, R any] interface {
 	//    it should return that modified value rather than creating a new one,
 	//    or alternatively, it can create and return a new combined result.
 	// 5. Context cancellation is respected across workers.
-	// 6. Type T is derived by json-deserializing the file contents, and any issues in deserialization
-	//    must be handled within the worker.
+	// 6. Values of type T are derived by decoding the file contents with the Decoder chosen
+	//    in the Configuration (JSON by default), a file may contain several values.
+	//    Values are passed to accumulators, while the file is decoded, so values of a file,
+	//    which fails in the middle, are accumulated up to the error.
+	//    Any issues in decoding are handled within the worker.
 	// 7. The combiner function will wait for all workers to complete, ensuring no goroutine leaks
 	//    occur during the process.
+	// 8. Errors of files and directories are reported as FileErrors together with the partial result.
+	//    Whether they stop the crawling is defined by the ErrorPolicy of the Configuration.
 	Collect(
 		ctx context.Context,
 		fileSystem fs.FileSystem,
@@ -60,6 +119,29 @@ type Crawler[T, R any] interface {
 		accumulator workerpool.Accumulator[T, R],
 		combiner Combiner[R],
 	) (R, error)
+
+	// Start runs Collect in the background and returns the handle, which reports progress of crawling,
+	// cancels it and waits for its result. Events are passed to the Observer of the Configuration too.
+	Start(
+		ctx context.Context,
+		fileSystem fs.FileSystem,
+		root string,
+		conf Configuration,
+		accumulator workerpool.Accumulator[T, R],
+		combiner Combiner[R],
+	) *Job[R]
+
+	// CollectIncremental works like Collect, but opens only files changed since the previous run
+	// described by the manifest of inc, and returns the manifest for the next run. See Incremental.
+	CollectIncremental(
+		ctx context.Context,
+		fileSystem fs.FileSystem,
+		root string,
+		conf Configuration,
+		accumulator workerpool.Accumulator[T, R],
+		combiner Combiner[R],
+		inc Incremental[R],
+	) (R, *Manifest, error)
 }
 
 type crawlerImpl[T, R any] struct{}
@@ -68,43 +150,102 @@ func New[T, R any]() *crawlerImpl[T, R] {
 	return &crawlerImpl[T, R]{}
 }
 
-// The function which uses to catch panic. It writes error about panic to inputted chan
-var catch = func(output chan error) {
+// The function which uses to catch panic. It writes error about panic in the given stage of processing of path to inputted chan
+var catch = func(output chan FileError, path string, stage *Stage) {
 	if x := recover(); x != nil {
 		switch tp := x.(type) {
 		case error: // if value in caught panic is error
-			output <- tp // writes its
+			output <- FileError{Path: path, Stage: *stage, Err: tp, Panic: true} // writes its
 		default:
-			output <- fmt.Errorf("Stopped due to panic: %#v", tp) // creates its own panic error
+			output <- FileError{Path: path, Stage: *stage, Err: fmt.Errorf("Stopped due to panic: %#v", tp), Panic: true} // creates its own panic error
 		}
 	}
 }
 
-// The function searches from root directory all files and returns output chan of paths to these files.
+// The function searches from root directory all files passed through the filter and returns output chan of paths to these files.
 // Besides tools for working it accepts chan of error. It will write caught error or error about panic to this chan
 // so that called function can determine whether there was an error
-func (c *crawlerImpl[T, R]) search(ctx context.Context, workers int, root string, fileSystem fs.FileSystem, err chan error) <-chan string {
+func (c *crawlerImpl[T, R]) search(ctx context.Context, conf *Configuration, root string, fileSystem fs.FileSystem, filter *filter, err chan FileError) <-chan string {
 	files := make(chan string) // output chan of paths to found files
 	go func() {
-		defer close(files)                                               // asynchronous closes the channel
-		poolSearch := workerpool.New[string, string]()                   // creates workerpool
-		poolSearch.List(ctx, workers, root, func(node string) []string { // uses its method List
-			defer catch(err)                       // catches panic and writes about it to inputted chan err
-			entries, e := fileSystem.ReadDir(node) // gets []os.DirEntry by fileSystems
-			if e != nil {                          // if there was an error
-				err <- e // stops the working
+		defer close(files) // asynchronous closes the channel
+		start := &dirNode{path: root}
+		stage := StageSearch
+		var visited *visitedDirs               // directories found by the search, they are tracked only, when links are followed
+		if filter.symlinks == SymlinksFollow { // information about root is needed to detect links to it
+			defer catch(err, root, &stage) // catches panic and writes about it to inputted chan err
+			info, e := fileSystem.Stat(root)
+			if e != nil { // if root can't be described
+				err <- FileError{Path: root, Stage: StageSearch, Err: e} // stops the working
+				return
+			}
+			start.info = info
+			visited = &visitedDirs{keys: make(map[any]struct{})}
+			visited.add(info)
+		}
+		poolSearch := workerpool.New[*dirNode, *dirNode]()                               // creates workerpool
+		poolSearch.List(ctx, conf.SearchWorkers, start, func(node *dirNode) []*dirNode { // uses its method List
+			defer catch(err, node.path, &stage)         // catches panic and writes about it to inputted chan err
+			entries, e := fileSystem.ReadDir(node.path) // gets []os.DirEntry by fileSystems
+			if e != nil {                               // if there was an error
+				err <- FileError{Path: node.path, Stage: StageSearch, Err: e} // stops the working or skips the directory
 				return nil
 			}
-			ans := make([]string, 0) // creates slice of child elements as Searcher function
+			conf.Observer.notify(Event{Kind: EventDirVisited, Path: node.path})
+			ans := make([]*dirNode, 0) // creates slice of child elements as Searcher function
 			for _, entry := range entries {
-				path := fileSystem.Join(node, entry.Name()) // creates path to considered os.DirEntry
-				if entry.IsDir() {                          // if it is directory
-					ans = append(ans, path) // appends it to child elements to considered its to next layer
-				} else {
-					select {
-					case <-ctx.Done(): // stops if context is closed
-					case files <- path: // tries to write found path to files
+				name := entry.Name()
+				if filter.skipName(name) { // hidden entries are skipped without any other requests
+					continue
+				}
+				child := node.child(fileSystem.Join(node.path, name), name) // creates node of considered os.DirEntry
+				isDir := entry.IsDir()
+				var info os.FileInfo // information about the target of the link, it is requested only for links
+				if filter.symlinks != SymlinksAsEntries && entry.Type()&os.ModeSymlink != 0 {
+					if filter.symlinks == SymlinksSkip {
+						continue
+					}
+					if info, e = fileSystem.Stat(child.path); e != nil { // if link is broken
+						err <- FileError{Path: child.path, Stage: StageSearch, Err: e} // stops the working or skips the link
+						continue
+					}
+					if isDir = info.IsDir(); isDir && node.within(info) { // link to the parent makes a cycle
+						continue
+					}
+				}
+
+				if isDir { // if it is directory
+					if filter.skipDir(child, name) {
+						continue
 					}
+					if filter.symlinks == SymlinksFollow && info == nil { // information about directories is needed to detect cycles
+						if info, e = entry.Info(); e != nil {
+							err <- FileError{Path: child.path, Stage: StageSearch, Err: e} // stops the working or skips the directory
+							continue
+						}
+					}
+					if visited != nil && !visited.add(info) { // the directory has been found through another link or by its path
+						continue
+					}
+					child.info = info
+					ans = append(ans, child) // appends it to child elements, which are searched by idle workers or depth-first by this one
+					continue
+				}
+				if filter.skipFile(child, name) {
+					continue
+				}
+				large, e := filter.tooLarge(entry, info)
+				if e != nil { // if file can't be described
+					err <- FileError{Path: child.path, Stage: StageSearch, Err: e} // stops the working or skips the file
+					continue
+				}
+				if large {
+					continue
+				}
+				select {
+				case <-ctx.Done(): // stops if context is closed
+				case files <- child.path: // tries to write found path to files
+					conf.Observer.notify(Event{Kind: EventFileFound, Path: child.path})
 				}
 			}
 			return ans // returns child elements
@@ -113,33 +254,91 @@ func (c *crawlerImpl[T, R]) search(ctx context.Context, workers int, root string
 	return files // returns output chan
 }
 
-// The function deserializes found files to type T and returns chan of processed values of type T.
+// The function decodes found files to values of type T and returns chan of single values. Values are sent,
+// while the file is decoded, so that values of the same file can be accumulated by different workers
+// and the file isn't held in memory as a whole. Every file enters the budget before opening and is released,
+// when all its values have been passed on.
 // Besides tools for working it accepts chan of error. It will write caught error or error about panic to this chan
 // so that called function can determine whether there was an error
-func (c *crawlerImpl[T, R]) makeDeserialization(ctx context.Context, workers int, inp <-chan string, fileSystem fs.FileSystem, err chan error) <-chan T {
-	poolTransform := workerpool.New[string, T]()                                  // creates workerpool
-	jsons := poolTransform.Transform(ctx, workers, inp, func(filePath string) T { // uses its method Transform
-		defer catch(err)                     // catches panic and writes about it to inputted chan err
-		file, e := fileSystem.Open(filePath) // opens inputted file to deserialization
-		var t T
-		defer func() { // delayed file closure
-			if file != nil {
-				if e = file.Close(); e != nil { // Tries to close file and  if it fails
-					println("Error ", e.Error(), " closing the file by path: ", filePath) // logs it to stderr
-				}
+func (c *crawlerImpl[T, R]) makeDeserialization(ctx context.Context, conf *Configuration, inp <-chan string, fileSystem fs.FileSystem, b *budget, err chan FileError) <-chan T {
+	values := make(chan T)                                                                          // output chan
+	poolTransform := workerpool.New[string, struct{}]()                                             // creates workerpool
+	decoded := poolTransform.Transform(ctx, conf.FileWorkers, inp, func(filePath string) struct{} { // uses its method Transform
+		stage := StageOpen
+		defer catch(err, filePath, &stage) // catches panic and writes about it to inputted chan err
+		var size int64
+		if b.needsSize() {
+			if info, e := fileSystem.Stat(filePath); e == nil { // file, which can't be described, fails on opening
+				size = info.Size()
 			}
-		}()
-		if e != nil { // if there was an error opening the file
-			err <- e // writes error to inputted chan
-			return t // returns null value of type T
 		}
-		e = json.NewDecoder(file).Decode(&t) // does deserialization by json decoder
-		if e != nil {                        // if there was an error opening the file
-			err <- e // writes error to inputted chan
+		taken := b.acquire(size) // waits, while too many files are in flight
+		defer b.release(taken)   // lets the next file in
+		sent := 0
+		defer func() { // values, which have been passed on, are counted, even if the file has failed
+			if sent > 0 {
+				conf.Observer.notify(Event{Kind: EventAccumulated, Path: filePath, Values: sent})
+			}
+		}()
+		if c.decodeFile(conf, fileSystem, filePath, &stage, err, nil, func(v T) {
+			values <- v // accumulator workers drain the chan, until it is closed
+			sent++
+		}) {
+			conf.Observer.notify(Event{Kind: EventFileDecoded, Path: filePath, Values: sent})
 		}
-		return t // returns processed value of type T
+		return struct{}{}
 	})
-	return jsons // returns output chan
+	go func() {
+		defer close(values) // asynchronous closes the channel, when all files are decoded
+		for range decoded { // waits for file workers
+		}
+	}()
+	return values // returns output chan
+}
+
+// The function opens the file, decodes its values one by one and passes them to emit. If the file fails,
+// the function writes error to err chan and returns false, values decoded before the error have been passed already.
+// Stage is updated on the way, so that panic caught by the caller is reported with the right stage.
+// If tee isn't nil, the whole contents of the file are written to it, even if the decoder doesn't read them to the end
+func (c *crawlerImpl[T, R]) decodeFile(conf *Configuration, fileSystem fs.FileSystem, filePath string, stage *Stage, err chan FileError, tee io.Writer, emit func(T)) bool {
+	*stage = StageOpen
+	file, e := fileSystem.Open(filePath) // opens inputted file to deserialization
+	defer func() {                       // delayed file closure
+		if file != nil {
+			if e := file.Close(); e != nil { // Tries to close file and  if it fails
+				println("Error ", e.Error(), " closing the file by path: ", filePath) // logs it to stderr
+			}
+		}
+	}()
+	if e != nil { // if there was an error opening the file
+		err <- FileError{Path: filePath, Stage: StageOpen, Err: e} // writes error to inputted chan
+		return false
+	}
+
+	*stage = StageDecode
+	var r io.Reader = file
+	if tee != nil {
+		r = io.TeeReader(file, tee)
+	}
+	stream := conf.decoderFor(filePath).NewStream(r) // chooses decoder by extension of the file
+	for {
+		var t T
+		e = stream.Decode(&t) // decodes the next value
+		if errors.Is(e, io.EOF) {
+			e = nil
+			if tee != nil { // the rest, which the decoder hasn't needed, is written too
+				_, e = io.Copy(tee, file)
+			}
+			if e == nil {
+				return true // all values of the file have been passed
+			}
+		}
+		if e != nil { // if the file is broken
+			err <- FileError{Path: filePath, Stage: StageDecode, Err: e} // writes error to inputted chan
+			return false
+		}
+		emit(t)
+	}
 }
 
 // The function creates worker that combines accumulated values of type R from different workers to one result value.
@@ -163,6 +362,31 @@ func (c *crawlerImpl[T, R]) combineValuesR(ctx context.Context, workers int, inp
 	return res // returns output chan
 }
 
+func (c *crawlerImpl[T, R]) Start(
+	ctx context.Context,
+	fileSystem fs.FileSystem,
+	root string,
+	conf Configuration,
+	accumulator workerpool.Accumulator[T, R],
+	combiner Combiner[R],
+) *Job[R] {
+	ctx, cancel := context.WithCancel(ctx) // context which is cancelled by Job.Cancel
+	job := &Job[R]{cancel: cancel, done: make(chan struct{})}
+
+	observer := conf.Observer // observer of the caller gets events after they are counted
+	conf.Observer = func(event Event) {
+		job.count(event)
+		observer.notify(event)
+	}
+
+	go func() {
+		defer close(job.done) // asynchronous closes the channel after result is written
+		defer cancel()        // releases resources of ctx
+		job.result, job.err = c.Collect(ctx, fileSystem, root, conf, accumulator, combiner)
+	}()
+	return job
+}
+
 func (c *crawlerImpl[T, R]) Collect(
 	ctx context.Context,
 	fileSystem fs.FileSystem,
@@ -171,7 +395,14 @@ func (c *crawlerImpl[T, R]) Collect(
 	accumulator workerpool.Accumulator[T, R],
 	combiner Combiner[R],
 ) (R, error) {
+	filter, e := newFilter(&conf) // checks patterns before any work
+	if e != nil {
+		var zero R
+		return zero, e
+	}
+
 	ctxErr, cancel := context.WithCancelCause(ctx) // creates from ctx new context with cancel function that accepts error - reason of canceling
+	defer cancel(nil)                              // releases resources of ctxErr after return
 
 	// creates a new context from ctxErr without the undo function so that workers in the pipeline
 	// do not stop their work earlier than workers at the beginning of the pipeline. They will stop
@@ -179,18 +410,32 @@ func (c *crawlerImpl[T, R]) Collect(
 	// with the first channel of the conveyor. This allows to avoid leakage of goroutines
 	ctxForPipeline := context.WithoutCancel(ctxErr)
 
-	err := make(chan error)                                                                        // chan so that workers in the pipeline can write the error that occurred to its
-	defer close(err)                                                                               // closes the chan err after return
-	files := c.search(ctxErr, conf.SearchWorkers, root, fileSystem, err)                           // chan of paths to files in directory root (and subdirectories)
-	jsons := c.makeDeserialization(ctxForPipeline, conf.FileWorkers, files, fileSystem, err)       // channel with json deserialized file values
-	res := c.combineValuesR(ctxForPipeline, conf.AccumulatorWorkers, jsons, accumulator, combiner) // result chan with one result value
+	err := make(chan FileError) // chan so that workers in the pipeline can write the error that occurred to its
+	defer close(err)            // closes the chan err after return
+	conf.setDefaults()
+	b := newBudget(&conf) // limits files in flight
+
+	files := c.search(ctxErr, &conf, root, fileSystem, filter, err)                                 // chan of paths to files in directory root (and subdirectories)
+	values := c.makeDeserialization(ctxForPipeline, &conf, files, fileSystem, b, err)               // channel with single decoded values
+	res := c.combineValuesR(ctxForPipeline, conf.AccumulatorWorkers, values, accumulator, combiner) // result chan with one result value
 
+	var failed FileErrors // errors of files and directories in the order of reporting
 	for {
 		select {
 		case e := <-err: // if there was an error in something worker
-			cancel(e) // calls cancel function with this error
+			failed = conf.report(failed, e, cancel)
 		case val := <-res: // if result value is calculated
-			return val, context.Cause(ctxErr) // returns this value and (perhaps) happened error
+			return val, collectError(ctx, failed) // returns this value and (perhaps) happened errors
 		}
 	}
 }
+
+// Function sets default decoders, if they aren't set
+func (c *Configuration) setDefaults() {
+	if c.Decoders == nil {
+		c.Decoders = DefaultDecoders()
+	}
+	if c.Decoder == nil {
+		c.Decoder = NewJSONDecoder()
+	}
+}
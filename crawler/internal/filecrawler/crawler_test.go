@@ -3,14 +3,12 @@ package crawler
 import (
 	"context"
 	"crawler/internal/fs"
-	"crawler/pkg/mocks"
 	"errors"
 	"math/rand/v2"
 	"os"
-	"path/filepath"
+	"path"
 	"runtime"
 	"strconv"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -18,7 +16,6 @@ import (
 	"unsafe"
 
 	"github.com/stretchr/testify/require"
-	"go.uber.org/mock/gomock"
 )
 
 func TestCancelContext(t *testing.T) {
@@ -97,9 +94,9 @@ func TestWithOsFileSystem(t *testing.T) {
 
 	c := New[TestType, TestAccumulator]()
 	result, err := c.Collect(ctx, fs.NewOsFileSystem(), rootDir, Configuration{
-		10,
-		10,
-		10,
+		SearchWorkers:      10,
+		FileWorkers:        10,
+		AccumulatorWorkers: 10,
 	}, accum, combiner)
 
 	require.NoError(t, err)
@@ -505,132 +502,109 @@ type errorsConfig struct {
 	dirReadError  bool
 }
 
-func runWithErrors(
-	ctx context.Context,
-	t testing.TB,
-	conf Configuration,
-	dirs int,
-	filesPerDir int,
-	cfg *errorsConfig,
-) (TestAccumulator, error) {
-	controller := gomock.NewController(t)
+// File system of tests, which slows down reading of the in-memory tree, injects errors and counts calls
+type testFileSystem struct {
+	fs.FileSystem
+	root string
+	cfg  *errorsConfig
 
-	t.Cleanup(func() {
-		controller.Finish()
-	})
+	joined, opened, closed, readDirs atomic.Int64
+}
 
+// File of testFileSystem
+type testFile struct {
+	fs.File
+	fileSystem *testFileSystem
+}
+
+// Function creates the tree of dirs directories with filesPerDir files in root
+func newTestFileSystem(t testing.TB, dirs int, filesPerDir int, cfg *errorsConfig) *testFileSystem {
+	tree := fs.NewMemFileSystem()
 	root := "root"
-	file := mocks.NewMockFile(controller)
+	for i := 0; i < dirs; i++ {
+		dir := strconv.FormatInt(rand.N[int64](10e9), 10) + strconv.Itoa(i)
+		for j := 0; j < filesPerDir; j++ {
+			file := strconv.FormatInt(rand.N[int64](10e9), 10) + strconv.Itoa(j)
+			require.NoError(t, tree.WriteFile(path.Join(root, dir, file), []byte(`{"data": 1}`)))
+		}
+	}
+	return &testFileSystem{FileSystem: tree, root: root, cfg: cfg}
+}
 
-	file.EXPECT().
-		Read(gomock.Any()).
-		DoAndReturn(func(p []byte) (n int, err error) {
-			if cfg.fileReadError {
-				return 0, ErrFileRead
-			}
+func (f *testFileSystem) Join(elem ...string) string {
+	f.joined.Add(1)
+	return f.FileSystem.Join(elem...)
+}
 
-			// ok for tests
-			return strings.NewReader(`{"data": 1}`).Read(p)
-		}).
-		AnyTimes()
+func (f *testFileSystem) Open(name string) (fs.File, error) {
+	if f.cfg.openFilePanic {
+		panic(ErrFileOpenPanic)
+	}
 
-	file.EXPECT().
-		Close().
-		DoAndReturn(func() error {
-			if rand.N(2) == 0 {
-				return errors.New("test")
-			}
+	if f.cfg.openFileError {
+		return nil, ErrFileOpen
+	}
 
-			return nil
-		}).
-		AnyTimes()
-
-	fileSystem := mocks.NewMockFileSystem(controller)
-
-	fileSystem.EXPECT().
-		Join(gomock.Any()).
-		DoAndReturn(func(elem ...string) string {
-			return filepath.Join(elem...)
-		}).
-		AnyTimes()
-
-	fileSystem.EXPECT().
-		Open(gomock.Any()).
-		DoAndReturn(
-			func(name string) (fs.File, error) {
-				if cfg.openFilePanic {
-					panic(ErrFileOpenPanic)
-				}
-
-				if cfg.openFileError {
-					return nil, ErrFileOpen
-				}
-
-				time.Sleep(sleepTime)
-				return file, nil
-			},
-		).
-		AnyTimes()
-
-	mockDir := mocks.NewMockDirEntry(controller)
-	mockDir.EXPECT().
-		Name().
-		DoAndReturn(func() string {
-			return strconv.FormatInt(rand.N[int64](10e9), 10)
-		}).
-		AnyTimes()
-
-	mockDir.EXPECT().
-		IsDir().
-		Return(true).
-		AnyTimes()
-
-	fileDirEntry := mocks.NewMockDirEntry(controller)
-	fileDirEntry.EXPECT().
-		Name().
-		Return(strconv.FormatInt(rand.N[int64](10e9), 10)).
-		AnyTimes()
-
-	fileDirEntry.EXPECT().
-		IsDir().
-		Return(false).
-		AnyTimes()
-
-	fileSystem.EXPECT().
-		ReadDir(root).
-		DoAndReturn(func(name string) ([]os.DirEntry, error) {
-			r := make([]os.DirEntry, 0)
-			for i := 0; i < dirs; i++ {
-				r = append(r, mockDir)
-			}
+	time.Sleep(sleepTime)
+	file, err := f.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
 
-			return r, nil
-		}).Times(1)
+	f.opened.Add(1)
+	return &testFile{File: file, fileSystem: f}, nil
+}
 
-	fileSystem.EXPECT().
-		ReadDir(gomock.Any()).
-		DoAndReturn(func(name string) ([]os.DirEntry, error) {
-			time.Sleep(sleepTime)
+func (f *testFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	if name != f.root {
+		time.Sleep(sleepTime)
 
-			if cfg.dirReadPanic {
-				panic(ErrReadDirPanic)
-			}
+		if f.cfg.dirReadPanic {
+			panic(ErrReadDirPanic)
+		}
 
-			if cfg.dirReadError {
-				return nil, ErrReadDir
-			}
+		if f.cfg.dirReadError {
+			return nil, ErrReadDir
+		}
+	}
 
-			r := make([]os.DirEntry, 0)
-			for i := 0; i < filesPerDir; i++ {
-				r = append(r, fileDirEntry)
-			}
+	f.readDirs.Add(1)
+	return f.FileSystem.ReadDir(name)
+}
+
+func (f *testFile) Read(p []byte) (int, error) {
+	if f.fileSystem.cfg.fileReadError {
+		return 0, ErrFileRead
+	}
+
+	return f.File.Read(p)
+}
+
+func (f *testFile) Close() error {
+	f.fileSystem.closed.Add(1)
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+
+	if rand.N(2) == 0 {
+		return errors.New("test")
+	}
 
-			return r, nil
-		}).
-		AnyTimes()
+	return nil
+}
+
+func runWithErrors(
+	ctx context.Context,
+	t testing.TB,
+	conf Configuration,
+	dirs int,
+	filesPerDir int,
+	cfg *errorsConfig,
+) (TestAccumulator, error) {
+	fileSystem := newTestFileSystem(t, dirs, filesPerDir, cfg)
 
 	c := New[TestType, TestAccumulator]()
-	result, err := c.Collect(ctx, fileSystem, root, conf, accum, combiner)
+	result, err := c.Collect(ctx, fileSystem, fileSystem.root, conf, accum, combiner)
 	require.LessOrEqual(t, runtime.NumGoroutine(), 3)
 
 	return result, err
@@ -643,103 +617,16 @@ func run(
 	dirs int,
 	filesPerDir int,
 ) (TestAccumulator, error) {
-	controller := gomock.NewController(t)
-
-	t.Cleanup(func() {
-		controller.Finish()
-	})
-
-	root := "root"
-	file := mocks.NewMockFile(controller)
-
-	file.EXPECT().
-		Read(gomock.Any()).
-		DoAndReturn(func(p []byte) (n int, err error) {
-			// ok for tests
-			return strings.NewReader(`{"data": 1}`).Read(p)
-		}).
-		Times(dirs * filesPerDir)
-
-	file.EXPECT().
-		Close().
-		DoAndReturn(func() error {
-			if rand.N(2) == 0 {
-				return errors.New("test")
-			}
-
-			return nil
-		}).
-		Times(dirs * filesPerDir)
-
-	fileSystem := mocks.NewMockFileSystem(controller)
-
-	fileSystem.EXPECT().
-		Join(gomock.Any()).
-		DoAndReturn(func(elem ...string) string {
-			return filepath.Join(elem...)
-		}).
-		Times(dirs + dirs*filesPerDir)
-
-	fileSystem.EXPECT().
-		Open(gomock.Any()).
-		DoAndReturn(
-			func(name string) (fs.File, error) {
-				time.Sleep(sleepTime)
-				return file, nil
-			},
-		).
-		Times(dirs * filesPerDir)
-
-	mockDir := mocks.NewMockDirEntry(controller)
-	mockDir.EXPECT().
-		Name().
-		DoAndReturn(func() string {
-			return strconv.FormatInt(rand.N[int64](10e9), 10)
-		}).Times(dirs)
-
-	mockDir.EXPECT().
-		IsDir().
-		Return(true).
-		Times(dirs)
-
-	fileDirEntry := mocks.NewMockDirEntry(controller)
-	fileDirEntry.EXPECT().
-		Name().
-		Return(strconv.FormatInt(rand.N[int64](10e9), 10)).
-		Times(dirs * filesPerDir)
-
-	fileDirEntry.EXPECT().
-		IsDir().
-		Return(false).
-		Times(dirs * filesPerDir)
-
-	fileSystem.EXPECT().
-		ReadDir(root).
-		DoAndReturn(func(name string) ([]os.DirEntry, error) {
-			r := make([]os.DirEntry, 0)
-			for i := 0; i < dirs; i++ {
-				r = append(r, mockDir)
-			}
-
-			return r, nil
-		}).Times(1)
-
-	fileSystem.EXPECT().
-		ReadDir(gomock.Any()).
-		DoAndReturn(func(name string) ([]os.DirEntry, error) {
-			time.Sleep(sleepTime)
-
-			r := make([]os.DirEntry, 0)
-			for i := 0; i < filesPerDir; i++ {
-				r = append(r, fileDirEntry)
-			}
-
-			return r, nil
-		}).Times(dirs)
+	fileSystem := newTestFileSystem(t, dirs, filesPerDir, &errorsConfig{})
 
 	c := New[TestType, TestAccumulator]()
-	result, err := c.Collect(ctx, fileSystem, root, conf, accum, combiner)
+	result, err := c.Collect(ctx, fileSystem, fileSystem.root, conf, accum, combiner)
 	require.LessOrEqual(t, runtime.NumGoroutine(), 3)
 
+	require.EqualValues(t, dirs+dirs*filesPerDir, fileSystem.joined.Load())
+	require.EqualValues(t, dirs*filesPerDir, fileSystem.opened.Load())
+	require.EqualValues(t, dirs*filesPerDir, fileSystem.closed.Load())
+	require.EqualValues(t, 1+dirs, fileSystem.readDirs.Load())
+
 	return result, err
 }
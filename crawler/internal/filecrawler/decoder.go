@@ -0,0 +1,266 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Count of bytes, which are looked at by the sniffing decoder to guess format of the file
+const sniffLen = 512
+
+// ErrUnsupportedType is returned by the CSV decoder, when values can't be stored in the type
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// Decoder turns the contents of a file into values of type T of the crawler.
+// Decoder must be thread-safe, because files are decoded by several workers at once,
+// but every Stream created by it is used by a single worker.
+type Decoder interface {
+	// NewStream returns the stream of values stored in r
+	NewStream(r io.Reader) Stream
+}
+
+// Stream decodes values of a single file one by one. Decoders of encoding/json, encoding/gob
+// and gopkg.in/yaml.v3 satisfy it, so they can be wrapped into DecoderFunc as they are.
+type Stream interface {
+	// Decode stores the next value in v, which is a pointer to the value of type T.
+	// It returns io.EOF, when there are no more values.
+	Decode(v any) error
+}
+
+// DecoderFunc is an adapter to use ordinary functions as Decoder
+type DecoderFunc func(r io.Reader) Stream
+
+// NewStream calls f(r)
+func (f DecoderFunc) NewStream(r io.Reader) Stream {
+	return f(r)
+}
+
+// DefaultDecoders returns decoders of the built-in formats by file extensions. It is used, when
+// Configuration.Decoders is nil. Every call returns a new map, so it can be changed and set to Configuration.
+func DefaultDecoders() map[string]Decoder {
+	ndjson := NewNDJSONDecoder()
+	return map[string]Decoder{
+		".json":   NewJSONDecoder(),
+		".ndjson": ndjson,
+		".jsonl":  ndjson,
+		".csv":    NewCSVDecoder(),
+		".gob":    NewGobDecoder(),
+	}
+}
+
+// NewJSONDecoder returns Decoder of files, which contain a single JSON value
+func NewJSONDecoder() Decoder {
+	return DecoderFunc(func(r io.Reader) Stream {
+		return &jsonStream{decoder: json.NewDecoder(r)}
+	})
+}
+
+// NewNDJSONDecoder returns Decoder of files, which contain a stream of JSON values, usually one value per line
+func NewNDJSONDecoder() Decoder {
+	return DecoderFunc(func(r io.Reader) Stream {
+		return json.NewDecoder(r)
+	})
+}
+
+// NewGobDecoder returns Decoder of files, which contain a stream of gob encoded values
+func NewGobDecoder() Decoder {
+	return DecoderFunc(func(r io.Reader) Stream {
+		return gob.NewDecoder(r)
+	})
+}
+
+// NewCSVDecoder returns Decoder of CSV files with the header. Every row is decoded to the struct:
+// the column goes to the field with the same name in the `csv` tag, in the `json` tag or in the name
+// of the field, names are compared case-insensitively. Unknown columns and empty cells are skipped.
+// Fields may be strings, booleans, numbers or implement encoding.TextUnmarshaler.
+func NewCSVDecoder() Decoder {
+	return DecoderFunc(func(r io.Reader) Stream {
+		return &csvStream{reader: csv.NewReader(r)}
+	})
+}
+
+// NewSniffDecoder returns Decoder, which guesses format by the first bytes of the file: the stream
+// of JSON values is expected, if the file starts with '{' or '[', gob is expected, if the beginning
+// isn't UTF-8 text, and CSV otherwise. It is useful for files without extensions.
+func NewSniffDecoder() Decoder {
+	return DecoderFunc(func(r io.Reader) Stream {
+		buffered := bufio.NewReaderSize(r, sniffLen)
+		head, err := buffered.Peek(sniffLen)
+		if err != nil && !errors.Is(err, io.EOF) { // short file is fine, it is read whole
+			return errStream{err}
+		}
+		switch trimmed := bytes.TrimLeft(head, " \t\r\n"); {
+		case len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '['):
+			return NewNDJSONDecoder().NewStream(buffered)
+		case !isText(head):
+			return NewGobDecoder().NewStream(buffered)
+		default:
+			return NewCSVDecoder().NewStream(buffered)
+		}
+	})
+}
+
+// Function reports whether b looks like text: it is valid UTF-8 without zero bytes. The last rune may be cut by the
+// end of b, so it isn't checked
+func isText(b []byte) bool {
+	if bytes.IndexByte(b, 0) >= 0 {
+		return false
+	}
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size == 1 && len(b) >= utf8.UTFMax { // invalid byte, which isn't the cut rune
+			return false
+		}
+		b = b[size:]
+	}
+	return true
+}
+
+// Stream, which returns the same error on every call
+type errStream struct {
+	err error
+}
+
+func (s errStream) Decode(any) error {
+	return s.err
+}
+
+// Stream of the single JSON value
+type jsonStream struct {
+	decoder *json.Decoder
+	done    bool // whether the value has been already decoded
+}
+
+func (s *jsonStream) Decode(v any) error {
+	if s.done {
+		return io.EOF
+	}
+	s.done = true
+	if err := s.decoder.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) { // file without the value is broken, unlike empty stream
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
+}
+
+// Stream of rows of the CSV file
+type csvStream struct {
+	reader  *csv.Reader
+	header  []string
+	columns []int // indices of fields of the struct by columns, -1 means that column is skipped
+	typ     reflect.Type
+}
+
+func (s *csvStream) Decode(v any) error {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("csv: %w %T, pointer to struct is expected", ErrUnsupportedType, v)
+	}
+	if s.header == nil { // the first row is the header
+		header, err := s.reader.Read()
+		if err != nil {
+			return err // empty file is empty stream, so io.EOF is returned as is
+		}
+		s.header = header
+	}
+	if s.typ != ptr.Elem().Type() { // fields are found once for the type
+		s.typ = ptr.Elem().Type()
+		s.columns = columnsOf(s.typ, s.header)
+	}
+
+	row, err := s.reader.Read()
+	if err != nil {
+		return err
+	}
+	value := ptr.Elem()
+	for i, cell := range row {
+		if i >= len(s.columns) || s.columns[i] < 0 || cell == "" {
+			continue
+		}
+		if err := setCell(value.Field(s.columns[i]), cell); err != nil {
+			line, _ := s.reader.FieldPos(i)
+			return fmt.Errorf("csv: line %d, column %q: %w", line, s.header[i], err)
+		}
+	}
+	return nil
+}
+
+// Function returns indices of exported fields of struct typ by the columns of header
+func columnsOf(typ reflect.Type, header []string) []int {
+	byName := make(map[string]int)
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			name = tag
+		}
+		if tag, _, _ := strings.Cut(field.Tag.Get("csv"), ","); tag != "" && tag != "-" {
+			name = tag // csv tag is more specific than json one
+		}
+		byName[strings.ToLower(name)] = i
+	}
+
+	columns := make([]int, len(header))
+	for i, name := range header {
+		index, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			index = -1
+		}
+		columns[i] = index
+	}
+	return columns
+}
+
+// Function parses cell and stores it in the field
+func setCell(field reflect.Value, cell string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(cell))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(cell, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(cell, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("%w %s", ErrUnsupportedType, field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"crawler/internal/fs"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var ErrCustomDecode = errors.New("custom decode")
+
+type CSVType struct {
+	Data  int64   `json:"data"`
+	Name  string  `csv:"title"`
+	Ratio float64 // matched by the name of the field
+	Flag  bool
+}
+
+func sum(current TestType, accum TestAccumulator) TestAccumulator {
+	accum.Sum += current.Data
+	return accum
+}
+
+func sumCombiner(first, second TestAccumulator) TestAccumulator {
+	second.Sum += first.Sum
+	return second
+}
+
+func writeFiles(t *testing.T, files map[string][]byte) string {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+	}
+	return root
+}
+
+func gobOf(t *testing.T, values ...TestType) []byte {
+	buf := new(bytes.Buffer)
+	encoder := gob.NewEncoder(buf)
+	for _, v := range values {
+		require.NoError(t, encoder.Encode(v))
+	}
+	return buf.Bytes()
+}
+
+func collectSum(t *testing.T, root string, conf Configuration) (int64, error) {
+	conf.SearchWorkers, conf.FileWorkers, conf.AccumulatorWorkers = 2, 2, 2
+	result, err := New[TestType, TestAccumulator]().Collect(context.Background(), fs.NewOsFileSystem(), root, conf, sum, sumCombiner)
+	return result.Sum, err
+}
+
+func decodeAll[T any](decoder Decoder, content string) ([]T, error) {
+	stream := decoder.NewStream(strings.NewReader(content))
+	values := make([]T, 0)
+	for {
+		var v T
+		err := stream.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			return values, nil
+		}
+		if err != nil {
+			return values, err
+		}
+		values = append(values, v)
+	}
+}
+
+func TestDefaultDecoders(t *testing.T) {
+	root := writeFiles(t, map[string][]byte{
+		"a.json":        []byte(`{"data": 1}`),
+		"b/c.ndjson":    []byte("{\"data\": 2}\n{\"data\": 3}\n"),
+		"b/d.JSONL":     []byte("{\"data\": 4}\n"),
+		"e.csv":         []byte("data,other\n5,x\n6,y\n"),
+		"f/g/h.gob":     gobOf(t, TestType{7}, TestType{8}),
+		"no-extension":  []byte(`{"data": 9}`),
+		"empty.ndjson":  nil,
+		"header.csv":    []byte("data\n"),
+		"f/g/empty.gob": nil,
+	})
+
+	total, err := collectSum(t, root, Configuration{})
+	require.NoError(t, err)
+	require.EqualValues(t, 45, total)
+}
+
+func TestSniffDecoder(t *testing.T) {
+	root := writeFiles(t, map[string][]byte{
+		"json":   []byte(" \n{\"data\": 1}\n{\"data\": 2}"),
+		"csv":    []byte("data\n3\n"),
+		"gob":    gobOf(t, TestType{4}),
+		"a.json": []byte(`{"data": 5}`),
+	})
+
+	total, err := collectSum(t, root, Configuration{Decoder: NewSniffDecoder()})
+	require.NoError(t, err)
+	require.EqualValues(t, 15, total)
+
+	arrays, err := decodeAll[[]int](NewSniffDecoder(), `[1, 2] [3]`)
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{1, 2}, {3}}, arrays)
+}
+
+func TestCustomDecoders(t *testing.T) {
+	lines := DecoderFunc(func(r io.Reader) Stream {
+		return json.NewDecoder(r)
+	})
+	root := writeFiles(t, map[string][]byte{
+		"a.data": []byte("{\"data\": 1}\n{\"data\": 2}"),
+		"b.json": []byte(`{"data": 10}`),
+	})
+
+	total, err := collectSum(t, root, Configuration{Decoders: map[string]Decoder{".data": lines}})
+	require.NoError(t, err)
+	require.EqualValues(t, 13, total) // unknown ".json" falls back to the JSON decoder
+
+	failing := DecoderFunc(func(io.Reader) Stream {
+		return errStream{ErrCustomDecode}
+	})
+	_, err = collectSum(t, root, Configuration{Decoders: map[string]Decoder{}, Decoder: failing})
+	require.ErrorIs(t, err, ErrCustomDecode)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	testCases := map[string][]byte{
+		"broken.json":  []byte(`{"data": `),
+		"empty.json":   nil,
+		"broken.jsonl": []byte("{\"data\": 1}\n{\"data\""),
+		"broken.csv":   []byte("data\nnot a number\n"),
+		"rows.csv":     []byte("data,other\n1\n"),
+		"broken.gob":   []byte("not a gob stream"),
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			root := writeFiles(t, map[string][]byte{
+				name:       content,
+				"ok.json":  []byte(`{"data": 1}`),
+				"ok.jsonl": []byte(`{"data": 1}`),
+			})
+
+			_, err := collectSum(t, root, Configuration{})
+			require.ErrorContains(t, err, name)
+		})
+	}
+}
+
+func TestCSVDecoder(t *testing.T) {
+	values, err := decodeAll[CSVType](NewCSVDecoder(), "TITLE,data,ratio,flag,skip,unknown\nfirst,1,0.5,true,7,x\n,2,,,,\n")
+	require.NoError(t, err)
+	require.Equal(t, []CSVType{
+		{Data: 1, Name: "first", Ratio: 0.5, Flag: true},
+		{Data: 2},
+	}, values)
+
+	_, err = decodeAll[int](NewCSVDecoder(), "data\n1\n")
+	require.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = decodeAll[struct{ Data []int }](NewCSVDecoder(), "data\n1\n")
+	require.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = decodeAll[CSVType](NewCSVDecoder(), "data\n1\n2\nthree\n")
+	require.ErrorContains(t, err, "line 4")
+}
+
+func TestJSONDecoderSingleValue(t *testing.T) {
+	values, err := decodeAll[TestType](NewJSONDecoder(), `{"data": 1} {"data": 2}`)
+	require.NoError(t, err)
+	require.Equal(t, []TestType{{1}}, values) // trailing values are ignored as before
+
+	_, err = decodeAll[TestType](NewJSONDecoder(), "  ")
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrorPolicy defines how Collect reacts to errors of single files and directories
+type ErrorPolicy int
+
+const (
+	// FailFast stops crawling on the first error. It is the default policy.
+	FailFast ErrorPolicy = iota
+	// SkipErrors skips failed files and directories, crawling is never stopped because of them
+	SkipErrors
+	// FailAfterErrors skips failed files and directories until Configuration.MaxErrors errors
+	// have happened, then crawling is stopped
+	FailAfterErrors
+)
+
+// Stage is the stage of the pipeline, where the error has happened
+type Stage int
+
+const (
+	// StageSearch is reading of directories and describing of their entries
+	StageSearch Stage = iota
+	// StageOpen is opening of files
+	StageOpen
+	// StageDecode is decoding of values from files
+	StageDecode
+)
+
+// FileError describes failure of a single file or directory
+type FileError struct {
+	Path  string // path to the file or the directory
+	Stage Stage  // stage of the pipeline, which has failed
+	Err   error  // the underlying error
+	Panic bool   // whether the error was recovered from a panic
+}
+
+// FileErrors is the error returned by Collect, when some files or directories have failed.
+// It lists failures in the order they have been reported.
+type FileErrors []FileError
+
+// String returns the name of the stage
+func (s Stage) String() string {
+	switch s {
+	case StageSearch:
+		return "search"
+	case StageOpen:
+		return "open"
+	case StageDecode:
+		return "decode"
+	default:
+		return "stage(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+func (e FileError) Error() string {
+	if e.Panic {
+		return fmt.Sprintf("%s %s: panic: %v", e.Stage, e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", e.Stage, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e FileError) Unwrap() error {
+	return e.Err
+}
+
+// Error describes the first failure and the count of others
+func (e FileErrors) Error() string {
+	switch len(e) {
+	case 0:
+		return "no errors"
+	case 1:
+		return e[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", e[0].Error(), len(e)-1)
+	}
+}
+
+// Unwrap returns all failures, so errors.Is and errors.As look through every one of them
+func (e FileErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fileErr := range e {
+		errs[i] = fileErr
+	}
+	return errs
+}
+
+// Function reports whether crawling must be stopped after count errors
+func (c *Configuration) stopsAfter(count int) bool {
+	switch c.ErrorPolicy {
+	case SkipErrors:
+		return false
+	case FailAfterErrors:
+		return count >= max(c.MaxErrors, 1)
+	default:
+		return true
+	}
+}
+
+// Function appends the error to failed ones, reports it to the observer and cancels crawling,
+// if the error policy doesn't allow to continue. It returns the new list of failed ones
+func (c *Configuration) report(failed FileErrors, e FileError, cancel context.CancelCauseFunc) FileErrors {
+	failed = append(failed, e)
+	c.Observer.notify(Event{Kind: EventError, Path: e.Path, Err: e})
+	if c.stopsAfter(len(failed)) { // if error policy doesn't allow to continue
+		cancel(e) // calls cancel function with this error
+	}
+	return failed
+}
+
+// Function returns error of Collect: cause of cancellation from outside and failures of files
+func collectError(ctx context.Context, failed FileErrors) error {
+	cause := context.Cause(ctx) // error of cancellation from outside
+	switch {
+	case len(failed) == 0:
+		return cause
+	case cause == nil:
+		return failed
+	default:
+		return errors.Join(cause, failed)
+	}
+}
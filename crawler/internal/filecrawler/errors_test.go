@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"context"
+	"crawler/internal/fs"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var ErrFaulty = errors.New("faulty")
+
+// File system, which fails reading of directories and opening of files with "fail" in their names and panics
+// on opening of files with "panic" in their names
+type faultyFileSystem struct {
+	fs.FileSystem
+}
+
+func (f faultyFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	if strings.Contains(filepath.Base(name), "fail") {
+		return nil, ErrFaulty
+	}
+	return f.FileSystem.ReadDir(name)
+}
+
+func (f faultyFileSystem) Open(name string) (fs.File, error) {
+	switch base := filepath.Base(name); {
+	case strings.Contains(base, "panic"):
+		panic("opening " + base)
+	case strings.Contains(base, "fail"):
+		return nil, ErrFaulty
+	}
+	return f.FileSystem.Open(name)
+}
+
+func collectFaulty(t *testing.T, root string, conf Configuration) (int64, error) {
+	conf.SearchWorkers, conf.FileWorkers, conf.AccumulatorWorkers = 1, 1, 1
+	result, err := New[TestType, TestAccumulator]().Collect(
+		context.Background(), faultyFileSystem{fs.NewOsFileSystem()}, root, conf, sum, sumCombiner)
+	return result.Sum, err
+}
+
+// Tree with good files of sum 7 and four broken entries
+var faultyTree = map[string][]byte{
+	"a.json":          []byte(`{"data": 1}`),
+	"b/c.json":        []byte(`{"data": 2}`),
+	"b/d.json":        []byte(`{"data": 4}`),
+	"broken.json":     []byte(`{"data": `),
+	"fail.json":       []byte(`{"data": 100}`),
+	"b/panic.json":    []byte(`{"data": 100}`),
+	"dir-fail/e.json": []byte(`{"data": 100}`),
+}
+
+func TestSkipErrors(t *testing.T) {
+	root := writeFiles(t, faultyTree)
+
+	total, err := collectFaulty(t, root, Configuration{ErrorPolicy: SkipErrors})
+	require.EqualValues(t, 7, total)
+
+	var failed FileErrors
+	require.ErrorAs(t, err, &failed)
+	require.Len(t, failed, 4)
+	require.ErrorIs(t, err, ErrFaulty)
+
+	slices.SortFunc(failed, func(a, b FileError) int { return strings.Compare(a.Path, b.Path) })
+	require.Equal(t, filepath.Join(root, "b", "panic.json"), failed[0].Path)
+	require.Equal(t, StageOpen, failed[0].Stage)
+	require.True(t, failed[0].Panic)
+
+	require.Equal(t, filepath.Join(root, "broken.json"), failed[1].Path)
+	require.Equal(t, StageDecode, failed[1].Stage)
+	require.False(t, failed[1].Panic)
+
+	require.Equal(t, filepath.Join(root, "dir-fail"), failed[2].Path)
+	require.Equal(t, StageSearch, failed[2].Stage)
+	require.ErrorIs(t, failed[2], ErrFaulty)
+
+	require.Equal(t, filepath.Join(root, "fail.json"), failed[3].Path)
+	require.Equal(t, StageOpen, failed[3].Stage)
+	require.ErrorIs(t, failed[3], ErrFaulty)
+}
+
+func TestFailAfterErrors(t *testing.T) {
+	root := writeFiles(t, faultyTree)
+
+	total, err := collectFaulty(t, root, Configuration{ErrorPolicy: FailAfterErrors, MaxErrors: 5})
+	require.EqualValues(t, 7, total) // four errors are below the limit
+	var failed FileErrors
+	require.ErrorAs(t, err, &failed)
+	require.Len(t, failed, 4)
+
+	tree := maps.Clone(faultyTree)
+	for i := range 10 {
+		tree[fmt.Sprintf("many/fail-%d.json", i)] = nil
+	}
+	root = writeFiles(t, tree)
+
+	_, err = collectFaulty(t, root, Configuration{ErrorPolicy: FailAfterErrors, MaxErrors: 5})
+	require.ErrorAs(t, err, &failed)
+	require.GreaterOrEqual(t, len(failed), 5)
+	require.Less(t, len(failed), 14) // crawling has been stopped before all files were opened
+}
+
+func TestFailFast(t *testing.T) {
+	root := writeFiles(t, map[string][]byte{
+		"a.json":    []byte(`{"data": 1}`),
+		"fail.json": nil,
+	})
+
+	_, err := collectFaulty(t, root, Configuration{})
+	require.ErrorIs(t, err, ErrFaulty)
+
+	var failed FileErrors
+	require.ErrorAs(t, err, &failed)
+	require.Equal(t, FileError{Path: filepath.Join(root, "fail.json"), Stage: StageOpen, Err: ErrFaulty}, failed[0])
+}
+
+func TestFileErrorsMessage(t *testing.T) {
+	failed := FileErrors{
+		{Path: "a.json", Stage: StageDecode, Err: ErrFaulty},
+		{Path: "b.json", Stage: StageOpen, Err: ErrFaulty, Panic: true},
+	}
+
+	require.Equal(t, "decode a.json: faulty", failed[0].Error())
+	require.Equal(t, "open b.json: panic: faulty", failed[1].Error())
+	require.Equal(t, "decode a.json: faulty (and 1 more errors)", failed.Error())
+	require.Equal(t, "search", StageSearch.String())
+	require.Equal(t, "stage(7)", Stage(7).String())
+}
@@ -0,0 +1,134 @@
+package crawler
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// EventKind is the kind of the event reported to Observer
+type EventKind int
+
+const (
+	// EventDirVisited is reported, when the directory has been read
+	EventDirVisited EventKind = iota
+	// EventFileFound is reported, when the file has passed the filter and is sent to decoding
+	EventFileFound
+	// EventFileDecoded is reported, when all values of the file have been decoded
+	EventFileDecoded
+	// EventFileReused is reported by CollectIncremental, when the file hasn't changed
+	// and its partial result is taken from the manifest
+	EventFileReused
+	// EventAccumulated is reported, when all values of the file have been passed to the accumulator.
+	// It is reported once per file rather than per value, so that counting doesn't slow down accumulation
+	EventAccumulated
+	// EventError is reported, when the file or the directory has failed
+	EventError
+)
+
+// Event describes a single step of crawling
+type Event struct {
+	Kind   EventKind
+	Path   string // path to the directory or the file
+	Values int    // count of values of the file for EventFileDecoded and EventAccumulated
+	Err    error  // FileError for EventError
+}
+
+// Observer receives events of crawling. It is called synchronously by workers of all stages,
+// so it must be thread-safe and fast, slow observer slows down crawling.
+type Observer func(event Event)
+
+// Progress counts events of crawling
+type Progress struct {
+	DirsVisited  int64 // count of read directories
+	FilesFound   int64 // count of files sent to decoding
+	FilesDecoded int64 // count of files, which have been decoded without errors
+	FilesReused  int64 // count of unchanged files skipped by CollectIncremental
+	Values       int64 // count of decoded values
+	Accumulated  int64 // count of values passed to the accumulator
+	Errors       int64 // count of failed files and directories
+}
+
+// Job is the handle of crawling started by Start. Its methods are thread-safe.
+type Job[R any] struct {
+	cancel context.CancelFunc
+	done   chan struct{} // closed, when crawling has finished
+
+	mu       sync.Mutex // guards progress
+	progress Progress
+
+	result R     // it is written before done is closed
+	err    error // it is written before done is closed
+}
+
+// Function calls observer, if it is set
+func (o Observer) notify(event Event) {
+	if o != nil {
+		o(event)
+	}
+}
+
+// String returns the name of the kind
+func (k EventKind) String() string {
+	switch k {
+	case EventDirVisited:
+		return "dir visited"
+	case EventFileFound:
+		return "file found"
+	case EventFileDecoded:
+		return "file decoded"
+	case EventFileReused:
+		return "file reused"
+	case EventAccumulated:
+		return "accumulated"
+	case EventError:
+		return "error"
+	default:
+		return "event(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Progress returns counters of events reported so far. O(1)
+func (j *Job[R]) Progress() Progress {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.progress
+}
+
+// Cancel stops crawling, Wait returns the partial result and context.Canceled. It does nothing,
+// if crawling has already finished.
+func (j *Job[R]) Cancel() {
+	j.cancel()
+}
+
+// Done returns the channel, which is closed, when crawling has finished
+func (j *Job[R]) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait waits for the end of crawling and returns what Collect would have returned
+func (j *Job[R]) Wait() (R, error) {
+	<-j.done
+	return j.result, j.err
+}
+
+// Function counts the event
+func (j *Job[R]) count(event Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch event.Kind {
+	case EventDirVisited:
+		j.progress.DirsVisited++
+	case EventFileFound:
+		j.progress.FilesFound++
+	case EventFileDecoded:
+		j.progress.FilesDecoded++
+		j.progress.Values += int64(event.Values)
+	case EventFileReused:
+		j.progress.FilesReused++
+	case EventAccumulated:
+		j.progress.Accumulated += int64(event.Values)
+	case EventError:
+		j.progress.Errors++
+	}
+}
@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"context"
+	"crawler/internal/fs"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserver(t *testing.T) {
+	root := writeFiles(t, map[string][]byte{
+		"a.json":       []byte(`{"data": 1}`),
+		"b/c.ndjson":   []byte("{\"data\": 2}\n{\"data\": 4}"),
+		"b/d/e.json":   []byte(`{"data": 8}`),
+		"b/broken.csv": []byte("data\nx\n"),
+	})
+
+	mu := sync.Mutex{}
+	events := make(map[EventKind]int)
+	observer := func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events[event.Kind]++
+		if event.Kind == EventError {
+			require.ErrorContains(t, event.Err, "broken.csv")
+		}
+	}
+
+	job := New[TestType, TestAccumulator]().Start(context.Background(), fs.NewOsFileSystem(), root, Configuration{
+		SearchWorkers:      2,
+		FileWorkers:        2,
+		AccumulatorWorkers: 2,
+		ErrorPolicy:        SkipErrors,
+		Observer:           observer,
+	}, sum, sumCombiner)
+
+	result, err := job.Wait()
+	require.Error(t, err)
+	require.EqualValues(t, 15, result.Sum)
+	require.Equal(t, Progress{
+		DirsVisited:  3,
+		FilesFound:   4,
+		FilesDecoded: 3,
+		Values:       4,
+		Accumulated:  4,
+		Errors:       1,
+	}, job.Progress())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, map[EventKind]int{
+		EventDirVisited:  3,
+		EventFileFound:   4,
+		EventFileDecoded: 3,
+		EventAccumulated: 3, // accumulations are reported by the file
+		EventError:       1,
+	}, events)
+}
+
+func TestJobCancel(t *testing.T) {
+	files := make(map[string][]byte)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		files[name+".json"] = []byte(`{"data": 1}`)
+	}
+	root := writeFiles(t, files)
+
+	started := make(chan struct{})
+	once := sync.Once{}
+	slow := func(current TestType, accum TestAccumulator) TestAccumulator {
+		once.Do(func() { close(started) })
+		time.Sleep(50 * time.Millisecond)
+		return sum(current, accum)
+	}
+
+	job := New[TestType, TestAccumulator]().Start(context.Background(), fs.NewOsFileSystem(), root, Configuration{
+		SearchWorkers:      1,
+		FileWorkers:        1,
+		AccumulatorWorkers: 1,
+	}, slow, sumCombiner)
+
+	<-started
+	require.NotZero(t, job.Progress().FilesFound)
+	job.Cancel()
+	<-job.Done()
+
+	result, err := job.Wait()
+	require.ErrorIs(t, err, context.Canceled)
+	require.Less(t, result.Sum, int64(8))
+	job.Cancel() // cancellation of finished job does nothing
+}
+
+func TestEventKindString(t *testing.T) {
+	require.Equal(t, "file decoded", EventFileDecoded.String())
+	require.Equal(t, "event(9)", EventKind(9).String())
+}
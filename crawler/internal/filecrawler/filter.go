@@ -0,0 +1,197 @@
+package crawler
+
+import (
+	"crawler/internal/fs"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// SymlinkPolicy defines how the search stage treats symbolic links
+type SymlinkPolicy int
+
+const (
+	// SymlinksAsEntries treats links like other entries of the directory: they aren't descended,
+	// even if they point to directories, and are opened as files. It is the default policy.
+	SymlinksAsEntries SymlinkPolicy = iota
+	// SymlinksSkip skips all links
+	SymlinksSkip
+	// SymlinksFollow follows links to files and directories. Every directory is crawled once, even if several
+	// links lead to it, the path it is found by first is used. If the file system doesn't tell identities of files
+	// (see fs.FileKey), only a link to one of the directories, which contain it, is skipped to avoid cycles, so
+	// a directory reached through several links is crawled several times. Files reached through several links
+	// to files are crawled once per link.
+	SymlinksFollow
+)
+
+// The filter decides which entries found by the search stage are crawled. It is created once for Collect
+// and is read-only, so workers of the search stage share it
+type filter struct {
+	include    [][]string // split patterns of files to crawl, empty means all files
+	exclude    [][]string // split patterns of files and directories to skip
+	maxDepth   int
+	skipHidden bool
+	symlinks   SymlinkPolicy
+	maxSize    int64
+}
+
+// Node of the tree of directories walked by the search stage
+type dirNode struct {
+	path   string      // path to the directory in the file system
+	rel    string      // slash separated path relative to root, empty for root
+	depth  int         // count of directories between root and the node, zero for root
+	info   os.FileInfo // information about the directory, it is set only to detect cycles of links
+	parent *dirNode
+}
+
+// The set of directories already found by the search stage, it is used only with SymlinksFollow,
+// so that the directory reached through several links isn't crawled several times
+type visitedDirs struct {
+	mu   sync.Mutex
+	keys map[any]struct{} // identities of directories by fs.FileKey
+}
+
+// Factory of filter from the configuration, it checks patterns
+func newFilter(conf *Configuration) (*filter, error) {
+	f := &filter{
+		maxDepth:   conf.MaxDepth,
+		skipHidden: conf.SkipHidden,
+		symlinks:   conf.Symlinks,
+		maxSize:    conf.MaxFileSize,
+	}
+	var err error
+	if f.include, err = splitPatterns(conf.Include); err != nil {
+		return nil, fmt.Errorf("include: %w", err)
+	}
+	if f.exclude, err = splitPatterns(conf.Exclude); err != nil {
+		return nil, fmt.Errorf("exclude: %w", err)
+	}
+	return f, nil
+}
+
+// Function splits patterns by slashes and checks that every part is valid pattern of path.Match
+func splitPatterns(patterns []string) ([][]string, error) {
+	split := make([][]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		parts := strings.Split(strings.Trim(pattern, "/"), "/")
+		for _, part := range parts {
+			if _, err := path.Match(part, ""); err != nil { // malformed pattern is reported regardless of the name
+				return nil, fmt.Errorf("%q: %w", pattern, err)
+			}
+		}
+		split = append(split, parts)
+	}
+	return split, nil
+}
+
+// Function reports whether the entry matches any of patterns. Pattern without slashes is matched against the name
+// of the entry, otherwise it is matched against the whole relative path, where "**" matches any count of directories
+func matchAny(patterns [][]string, rel string, name string) bool {
+	for _, parts := range patterns {
+		if len(parts) == 1 && parts[0] != "**" {
+			if ok, _ := path.Match(parts[0], name); ok {
+				return true
+			}
+		} else if matchParts(parts, strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function matches parts of the pattern against elements of the path
+func matchParts(parts []string, elems []string) bool {
+	for len(parts) > 0 {
+		if parts[0] == "**" {
+			for i := 0; i <= len(elems); i++ { // "**" takes i elements
+				if matchParts(parts[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(parts[0], elems[0]); !ok {
+			return false
+		}
+		parts, elems = parts[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
+
+// Function returns child node of the directory
+func (n *dirNode) child(path string, name string) *dirNode {
+	rel := name
+	if n.rel != "" {
+		rel = n.rel + "/" + name
+	}
+	return &dirNode{path: path, rel: rel, depth: n.depth + 1, parent: n}
+}
+
+// Function reports whether the directory described by info is the node or one of its parents
+func (n *dirNode) within(info os.FileInfo) bool {
+	for ; n != nil; n = n.parent {
+		if n.info != nil && fs.SameFile(n.info, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function adds the directory described by info to the set and reports whether it hasn't been found before.
+// The directory, whose identity isn't known, is always new
+func (v *visitedDirs) add(info os.FileInfo) bool {
+	key, ok := fs.FileKey(info)
+	if !ok {
+		return true
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, found := v.keys[key]; found {
+		return false
+	}
+	v.keys[key] = struct{}{}
+	return true
+}
+
+// Function reports whether the entry is skipped before anything else is known about it
+func (f *filter) skipName(name string) bool {
+	return f.skipHidden && strings.HasPrefix(name, ".")
+}
+
+// Function reports whether the directory isn't descended
+func (f *filter) skipDir(dir *dirNode, name string) bool {
+	if f.maxDepth > 0 && dir.depth >= f.maxDepth { // its entries would be deeper than maxDepth
+		return true
+	}
+	return matchAny(f.exclude, dir.rel, name)
+}
+
+// Function reports whether the file isn't sent to the deserialization
+func (f *filter) skipFile(file *dirNode, name string) bool {
+	if f.maxDepth > 0 && file.depth > f.maxDepth {
+		return true
+	}
+	if len(f.include) > 0 && !matchAny(f.include, file.rel, name) {
+		return true
+	}
+	return matchAny(f.exclude, file.rel, name)
+}
+
+// Function reports whether the file is larger than the limit, info is requested from entry, if it isn't known yet
+func (f *filter) tooLarge(entry os.DirEntry, info os.FileInfo) (bool, error) {
+	if f.maxSize <= 0 {
+		return false, nil
+	}
+	if info == nil {
+		var err error
+		if info, err = entry.Info(); err != nil {
+			return false, err
+		}
+	}
+	return info.Size() > f.maxSize, nil
+}
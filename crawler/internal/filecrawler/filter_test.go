@@ -0,0 +1,163 @@
+package crawler
+
+import (
+	"context"
+	"crawler/internal/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tree of files, data of every file is its own power of two, so the sum shows, which files were crawled
+var filterTree = map[string][]byte{
+	"a.json":              []byte(`{"data": 1}`),
+	"README.md":           []byte("# not a json"),
+	"b/c.json":            []byte(`{"data": 2}`),
+	"b/d/e.json":          []byte(`{"data": 4}`),
+	"b/d/f/g.json":        []byte(`{"data": 8}`),
+	".hidden/h.json":      []byte(`{"data": 16}`),
+	"b/.i.json":           []byte(`{"data": 32}`),
+	"vendor/j.json":       []byte(`{"data": 64}`),
+	"large.json":          []byte(`{"data": 128, "padding": "` + strings.Repeat("x", 100) + `"}`),
+	"b/d/data.bin":        {0, 1, 2},
+	"b/d/f/notes/k.jsonl": []byte(`{"data": 256}`),
+}
+
+func TestFilter(t *testing.T) {
+	root := writeFiles(t, filterTree)
+
+	testCases := map[string]struct {
+		conf Configuration
+		sum  int64
+	}{
+		"names": {
+			conf: Configuration{Include: []string{"*.json"}},
+			sum:  1 + 2 + 4 + 8 + 16 + 32 + 64 + 128,
+		},
+		"several includes": {
+			conf: Configuration{Include: []string{"*.json", "*.jsonl"}, Exclude: []string{"large.json"}},
+			sum:  1 + 2 + 4 + 8 + 16 + 32 + 64 + 256,
+		},
+		"excluded directories": {
+			conf: Configuration{Include: []string{"*.json"}, Exclude: []string{"vendor", "b/d", "large.*"}},
+			sum:  1 + 2 + 16 + 32,
+		},
+		"double star": {
+			conf: Configuration{Include: []string{"b/**/*.json"}},
+			sum:  2 + 4 + 8 + 32,
+		},
+		"double star in the middle": {
+			conf: Configuration{Include: []string{"b/**/f/*"}},
+			sum:  8,
+		},
+		"leading double star": {
+			conf: Configuration{Include: []string{"**/d/*.json", "**/notes/**"}},
+			sum:  4 + 256,
+		},
+		"depth": {
+			conf: Configuration{Include: []string{"*.json"}, MaxDepth: 2},
+			sum:  1 + 2 + 16 + 32 + 64 + 128,
+		},
+		"depth of root files": {
+			conf: Configuration{Include: []string{"*.json"}, MaxDepth: 1},
+			sum:  1 + 128,
+		},
+		"hidden": {
+			conf: Configuration{Include: []string{"*.json"}, SkipHidden: true},
+			sum:  1 + 2 + 4 + 8 + 64 + 128,
+		},
+		"size": {
+			conf: Configuration{Exclude: []string{"*.md", "*.bin"}, MaxFileSize: 64},
+			sum:  1 + 2 + 4 + 8 + 16 + 32 + 64 + 256,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			total, err := collectSum(t, root, tt.conf)
+			require.NoError(t, err)
+			require.EqualValues(t, tt.sum, total)
+		})
+	}
+}
+
+func TestFilterExcludedFilesAreNotOpened(t *testing.T) {
+	root := writeFiles(t, filterTree)
+
+	_, err := collectSum(t, root, Configuration{})
+	require.ErrorContains(t, err, "README.md") // without the filter stray files abort Collect
+
+	total, err := collectSum(t, root, Configuration{Exclude: []string{"*.md", "*.bin"}})
+	require.NoError(t, err)
+	require.EqualValues(t, 511, total)
+}
+
+func TestFilterBadPattern(t *testing.T) {
+	_, err := collectSum(t, t.TempDir(), Configuration{Include: []string{"a/[b"}})
+	require.ErrorIs(t, err, path.ErrBadPattern)
+
+	_, err = collectSum(t, t.TempDir(), Configuration{Exclude: []string{"\\"}})
+	require.ErrorIs(t, err, path.ErrBadPattern)
+}
+
+func TestSymlinks(t *testing.T) {
+	root := writeFiles(t, map[string][]byte{
+		"a/b.json":       []byte(`{"data": 1}`),
+		"outside/c.json": []byte(`{"data": 2}`),
+		"outside/d.json": []byte(`{"data": 4}`),
+	})
+	require.NoError(t, os.Symlink(filepath.Join(root, "outside"), filepath.Join(root, "a", "dir-link")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "outside", "d.json"), filepath.Join(root, "a", "file-link.json")))
+	require.NoError(t, os.Symlink(root, filepath.Join(root, "a", "cycle")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "a"), filepath.Join(root, "outside", "cycle")))
+
+	t.Run("follow", func(t *testing.T) {
+		total, err := collectSum(t, root, Configuration{Symlinks: SymlinksFollow})
+		require.NoError(t, err)
+		// every directory is crawled once, so outside isn't crawled again through a/dir-link
+		// and a isn't crawled again through outside/cycle, but the link to the file is crawled
+		require.EqualValues(t, 1+4+2+4, total)
+	})
+
+	t.Run("diamond", func(t *testing.T) {
+		target := writeFiles(t, map[string][]byte{"e.json": []byte(`{"data": 8}`)})
+		diamond := writeFiles(t, map[string][]byte{"f.json": []byte(`{"data": 16}`)})
+		require.NoError(t, os.Symlink(target, filepath.Join(diamond, "left")))
+		require.NoError(t, os.Symlink(target, filepath.Join(diamond, "right")))
+
+		total, err := collectSum(t, diamond, Configuration{Symlinks: SymlinksFollow})
+		require.NoError(t, err)
+		require.EqualValues(t, 8+16, total) // the target of both links isn't counted twice
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		total, err := collectSum(t, root, Configuration{Symlinks: SymlinksSkip})
+		require.NoError(t, err)
+		require.EqualValues(t, 1+2+4, total)
+	})
+
+	t.Run("as entries", func(t *testing.T) {
+		_, err := collectSum(t, root, Configuration{Exclude: []string{"cycle"}})
+		require.ErrorContains(t, err, "dir-link") // link to the directory is opened as the file
+	})
+
+	t.Run("in memory", func(t *testing.T) {
+		tree := fs.NewMemFileSystem()
+		require.NoError(t, tree.WriteFile("root/a/b.json", []byte(`{"data": 1}`)))
+		require.NoError(t, tree.WriteFile("root/outside/c.json", []byte(`{"data": 2}`)))
+		require.NoError(t, tree.WriteFile("root/outside/d.json", []byte(`{"data": 4}`)))
+		require.NoError(t, tree.Symlink("../outside", "root/a/dir-link"))
+		require.NoError(t, tree.Symlink("/root/outside/d.json", "root/a/file-link.json"))
+		require.NoError(t, tree.Symlink("..", "root/a/cycle"))
+		require.NoError(t, tree.Symlink("/root/a", "root/outside/cycle"))
+
+		total, err := New[TestType, TestAccumulator]().Collect(context.Background(), tree, "root",
+			Configuration{SearchWorkers: 2, FileWorkers: 2, AccumulatorWorkers: 2, Symlinks: SymlinksFollow}, sum, sumCombiner)
+		require.NoError(t, err)
+		require.EqualValues(t, 1+4+2+4, total.Sum)
+	})
+}
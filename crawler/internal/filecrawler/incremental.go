@@ -0,0 +1,318 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"crawler/internal/fs"
+	"crawler/internal/workerpool"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrNoManifest is returned by CollectIncremental, when Incremental.Manifest or Incremental.Codec isn't set
+var ErrNoManifest = errors.New("incremental crawling needs manifest and codec")
+
+// Manifest remembers files processed by the previous run of CollectIncremental and their partial results.
+// Files are identified by paths built by the file system from root, so the next run must use the same root.
+type Manifest struct {
+	Files map[string]ManifestEntry `json:"files"`
+	Total []byte                   `json:"total,omitempty"` // result of the run encoded by Codec
+}
+
+// ManifestEntry describes the processed file
+type ManifestEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Hash    string    `json:"hash"`    // hex encoded SHA-256 of the contents
+	Partial []byte    `json:"partial"` // result of accumulation of values of the file encoded by Codec
+}
+
+// Codec encodes partial results of files to store them in Manifest. It must be thread-safe.
+type Codec[R any] interface {
+	Encode(value R) ([]byte, error)
+	Decode(data []byte) (R, error)
+}
+
+// Inverse removes the partial result of the file from the accumulated one, so that combiner(removed, inverse(accum, removed))
+// equals accum. For example, it is subtraction for sums.
+type Inverse[R any] func(accum R, removed R) R
+
+// Incremental configures CollectIncremental
+type Incremental[R any] struct {
+	// Manifest of the previous run, empty manifest means the first run. It isn't changed by CollectIncremental,
+	// which returns the manifest for the next run.
+	Manifest *Manifest
+	// Codec of partial results
+	Codec Codec[R]
+	// Inverse of the combiner. If it is set, the result of the previous run is corrected by removed and changed files
+	// only, otherwise partial results of all unchanged files are decoded and combined again.
+	Inverse Inverse[R]
+}
+
+// NewManifest returns empty manifest for the first run
+func NewManifest() *Manifest {
+	return &Manifest{Files: make(map[string]ManifestEntry)}
+}
+
+// ReadManifest reads manifest written by Manifest.Write
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	m := NewManifest()
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	if m.Files == nil { // manifest may be written before the first run
+		m.Files = make(map[string]ManifestEntry)
+	}
+	return m, nil
+}
+
+// Write writes manifest as JSON
+func (m *Manifest) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(m)
+}
+
+// JSONCodec returns Codec, which encodes partial results as JSON
+func JSONCodec[R any]() Codec[R] {
+	return jsonCodec[R]{}
+}
+
+// GobCodec returns Codec, which encodes partial results by encoding/gob
+func GobCodec[R any]() Codec[R] {
+	return gobCodec[R]{}
+}
+
+type jsonCodec[R any] struct{}
+
+func (jsonCodec[R]) Encode(value R) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec[R]) Decode(data []byte) (R, error) {
+	var value R
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+type gobCodec[R any] struct{}
+
+func (gobCodec[R]) Encode(value R) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := gob.NewEncoder(buf).Encode(value)
+	return buf.Bytes(), err
+}
+
+func (gobCodec[R]) Decode(data []byte) (R, error) {
+	var value R
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// Outcome of processing of the file by CollectIncremental
+type filePartial[R any] struct {
+	path    string
+	entry   ManifestEntry // entry of the file in the new manifest
+	partial R             // partial result, which is combined with the total one
+	add     bool          // whether partial is combined, it isn't for unchanged files, when total is corrected by Inverse
+	old     *R            // partial result of the previous run, which is removed from total by Inverse
+	ok      bool          // false means that file has failed
+}
+
+// The state of the single run of CollectIncremental shared by file workers, it is read-only
+type incrementalRun[T, R any] struct {
+	crawler     *crawlerImpl[T, R]
+	conf        *Configuration
+	fileSystem  fs.FileSystem
+	accumulator workerpool.Accumulator[T, R]
+	inc         *Incremental[R]
+	budget      *budget
+	previous    *Manifest
+	useInverse  bool // whether total of the previous run is corrected instead of combining all partial results
+}
+
+// CollectIncremental works like Collect, but skips files, which haven't changed since the previous run described
+// by the manifest: their partial results are taken from the manifest. Changed and new files are opened, their values
+// are accumulated file by file, so AccumulatorWorkers isn't used. Removed and failed files are excluded from the result.
+// A file is unchanged, if its size and modification time are the same, or if the hash of its contents is the same,
+// the hash is computed, while the file is decoded, so every file is opened at most once.
+// The returned manifest describes this run, if it has finished without stopping, otherwise it is the previous one,
+// so it can always be passed to the next run. Both manifests share partial results of unchanged files.
+func (c *crawlerImpl[T, R]) CollectIncremental(
+	ctx context.Context,
+	fileSystem fs.FileSystem,
+	root string,
+	conf Configuration,
+	accumulator workerpool.Accumulator[T, R],
+	combiner Combiner[R],
+	inc Incremental[R],
+) (R, *Manifest, error) {
+	var total R // default value: the neutral element of type R
+	if inc.Manifest == nil || inc.Codec == nil {
+		return total, nil, ErrNoManifest
+	}
+	filter, e := newFilter(&conf) // checks patterns before any work
+	if e != nil {
+		return total, inc.Manifest, e
+	}
+
+	run := &incrementalRun[T, R]{
+		crawler:     c,
+		conf:        &conf,
+		fileSystem:  fileSystem,
+		accumulator: accumulator,
+		inc:         &inc,
+		budget:      newBudget(&conf),
+		previous:    inc.Manifest,
+		useInverse:  inc.Inverse != nil && inc.Manifest.Total != nil,
+	}
+	if run.useInverse { // the total of the previous run is the starting point
+		if total, e = inc.Codec.Decode(inc.Manifest.Total); e != nil {
+			return total, inc.Manifest, fmt.Errorf("decode total of manifest: %w", e)
+		}
+	}
+
+	ctxErr, cancel := context.WithCancelCause(ctx)  // creates from ctx new context with cancel function that accepts error - reason of canceling
+	defer cancel(nil)                               // releases resources of ctxErr after return
+	ctxForPipeline := context.WithoutCancel(ctxErr) // workers after search drain their input, as in Collect
+
+	err := make(chan FileError) // chan so that workers in the pipeline can write the error that occurred to its
+	defer close(err)            // closes the chan err after return
+	conf.setDefaults()
+
+	files := c.search(ctxErr, &conf, root, fileSystem, filter, err) // chan of paths to files in directory root (and subdirectories)
+	poolTransform := workerpool.New[string, filePartial[R]]()
+	partials := poolTransform.Transform(ctxForPipeline, conf.FileWorkers, files, func(filePath string) filePartial[R] {
+		return run.process(filePath, err)
+	})
+
+	var failed FileErrors                  // errors of files and directories in the order of reporting
+	seen := make(map[string]ManifestEntry) // entries of the new manifest
+	for partials != nil {
+		select {
+		case e := <-err: // if there was an error in something worker
+			failed = conf.report(failed, e, cancel)
+		case p, ok := <-partials:
+			if !ok { // all files have been processed
+				partials = nil
+				continue
+			}
+			if !p.ok {
+				continue
+			}
+			seen[p.path] = p.entry
+			if p.old != nil { // the changed file is removed from total before its new partial result is added
+				total = inc.Inverse(total, *p.old)
+			}
+			if p.add {
+				total = combiner(p.partial, total)
+			}
+		}
+	}
+
+	if context.Cause(ctxErr) != nil { // the run has stopped, so its result doesn't cover all files
+		return total, inc.Manifest, collectError(ctx, failed)
+	}
+	manifest, e := run.finish(&total, seen)
+	if e != nil {
+		return total, inc.Manifest, errors.Join(e, collectError(ctx, failed))
+	}
+	return total, manifest, collectError(ctx, failed)
+}
+
+// Function processes the single file: reuses its partial result from the manifest or decodes and accumulates it
+func (r *incrementalRun[T, R]) process(filePath string, err chan FileError) filePartial[R] {
+	stage := StageOpen
+	defer catch(err, filePath, &stage) // catches panic and writes about it to inputted chan err
+	info, e := r.fileSystem.Stat(filePath)
+	if e != nil {
+		err <- FileError{Path: filePath, Stage: StageOpen, Err: e}
+		return filePartial[R]{}
+	}
+
+	result := filePartial[R]{path: filePath, entry: ManifestEntry{Size: info.Size(), ModTime: info.ModTime()}, ok: true}
+	prev, existed := r.previous.Files[filePath]
+	if existed && prev.Size == result.entry.Size && prev.ModTime.Equal(result.entry.ModTime) {
+		stage = StageDecode
+		return r.reuse(result, prev, err) // the file is trusted to be the same without reading
+	}
+
+	taken := r.budget.acquire(result.entry.Size) // waits, while too many files are in flight
+	defer r.budget.release(taken)
+	h := sha256.New()
+	var partial R
+	values := 0
+	ok := r.crawler.decodeFile(r.conf, r.fileSystem, filePath, &stage, err, h, func(v T) { // the file is hashed, while it is decoded
+		partial = r.accumulator(v, partial) // partial result of the file is used only if the whole file is decoded
+		values++
+	})
+	if !ok {
+		return filePartial[R]{}
+	}
+	result.entry.Hash = hex.EncodeToString(h.Sum(nil))
+	if existed && prev.Hash == result.entry.Hash { // only metadata of the file has changed, so decoded values aren't needed
+		return r.reuse(result, prev, err)
+	}
+	r.conf.Observer.notify(Event{Kind: EventFileDecoded, Path: filePath, Values: values})
+
+	if existed && r.useInverse { // old partial result must be removed from total
+		old, e := r.inc.Codec.Decode(prev.Partial)
+		if e != nil {
+			err <- FileError{Path: filePath, Stage: StageDecode, Err: fmt.Errorf("decode partial result: %w", e)}
+			return filePartial[R]{}
+		}
+		result.old = &old
+	}
+	result.partial = partial
+	if values > 0 {
+		r.conf.Observer.notify(Event{Kind: EventAccumulated, Path: filePath, Values: values})
+	}
+	if result.entry.Partial, e = r.inc.Codec.Encode(result.partial); e != nil {
+		err <- FileError{Path: filePath, Stage: StageDecode, Err: fmt.Errorf("encode partial result: %w", e)}
+		return filePartial[R]{}
+	}
+	result.add = true
+	return result
+}
+
+// Function takes the partial result of the unchanged file from the manifest of the previous run
+func (r *incrementalRun[T, R]) reuse(result filePartial[R], prev ManifestEntry, err chan FileError) filePartial[R] {
+	result.entry.Hash = prev.Hash
+	result.entry.Partial = prev.Partial
+	r.conf.Observer.notify(Event{Kind: EventFileReused, Path: result.path})
+	if r.useInverse { // total of the previous run already contains it
+		return result
+	}
+	partial, e := r.inc.Codec.Decode(prev.Partial)
+	if e != nil {
+		err <- FileError{Path: result.path, Stage: StageDecode, Err: fmt.Errorf("decode partial result: %w", e)}
+		return filePartial[R]{}
+	}
+	result.partial, result.add = partial, true
+	return result
+}
+
+// Function removes files, which haven't been seen by the run, from total and returns the manifest of the run
+func (r *incrementalRun[T, R]) finish(total *R, seen map[string]ManifestEntry) (*Manifest, error) {
+	if r.useInverse {
+		for filePath, prev := range r.previous.Files {
+			if _, ok := seen[filePath]; ok {
+				continue
+			}
+			removed, e := r.inc.Codec.Decode(prev.Partial)
+			if e != nil { // total can't be corrected, so the manifest is kept
+				return nil, fmt.Errorf("decode partial result of %s: %w", filePath, e)
+			}
+			*total = r.inc.Inverse(*total, removed)
+		}
+	}
+	encoded, e := r.inc.Codec.Encode(*total)
+	if e != nil {
+		return nil, fmt.Errorf("encode total: %w", e)
+	}
+	return &Manifest{Files: seen, Total: encoded}, nil
+}
@@ -0,0 +1,154 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"crawler/internal/fs"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// File system, which counts opened files
+type countingFileSystem struct {
+	fs.FileSystem
+
+	mu     sync.Mutex
+	opened map[string]int
+}
+
+func newCountingFileSystem() *countingFileSystem {
+	return &countingFileSystem{FileSystem: fs.NewOsFileSystem(), opened: make(map[string]int)}
+}
+
+func (c *countingFileSystem) Open(name string) (fs.File, error) {
+	c.mu.Lock()
+	c.opened[filepath.Base(name)]++
+	c.mu.Unlock()
+	return c.FileSystem.Open(name)
+}
+
+// Function returns counts of opened files since the previous call
+func (c *countingFileSystem) reset() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	opened := c.opened
+	c.opened = make(map[string]int)
+	return opened
+}
+
+func subtract(accum TestAccumulator, removed TestAccumulator) TestAccumulator {
+	accum.Sum -= removed.Sum
+	return accum
+}
+
+// Function runs CollectIncremental and replaces the manifest of inc by the returned one
+func collectIncremental(t *testing.T, fileSystem fs.FileSystem, root string, inc *Incremental[TestAccumulator]) (int64, Progress) {
+	job := &Job[TestAccumulator]{}
+	result, manifest, err := New[TestType, TestAccumulator]().CollectIncremental(context.Background(), fileSystem, root, Configuration{
+		SearchWorkers:      2,
+		FileWorkers:        2,
+		AccumulatorWorkers: 2,
+		Observer:           job.count,
+	}, sum, sumCombiner, *inc)
+	require.NoError(t, err)
+	inc.Manifest = manifest
+	return result.Sum, job.Progress()
+}
+
+func TestIncremental(t *testing.T) {
+	for name, inverse := range map[string]Inverse[TestAccumulator]{"recompute": nil, "inverse": subtract} {
+		t.Run(name, func(t *testing.T) {
+			root := writeFiles(t, map[string][]byte{
+				"a.json":      []byte(`{"data": 1}`),
+				"b/c.json":    []byte(`{"data": 2}`),
+				"b/d.ndjson":  []byte("{\"data\": 4}\n{\"data\": 8}"),
+				"b/e/f.jsonl": []byte(`{"data": 16}`),
+			})
+			fileSystem := newCountingFileSystem()
+			inc := Incremental[TestAccumulator]{Manifest: NewManifest(), Codec: JSONCodec[TestAccumulator](), Inverse: inverse}
+
+			first := inc.Manifest
+			total, progress := collectIncremental(t, fileSystem, root, &inc)
+			require.EqualValues(t, 31, total)
+			require.EqualValues(t, 4, progress.FilesDecoded)
+			require.Len(t, inc.Manifest.Files, 4)
+			require.Empty(t, first.Files) // the manifest of the caller isn't changed
+			// files are hashed, while they are decoded, so they are opened once
+			require.Equal(t, map[string]int{"a.json": 1, "c.json": 1, "d.ndjson": 1, "f.jsonl": 1}, fileSystem.reset())
+			hash := sha256.Sum256([]byte("{\"data\": 4}\n{\"data\": 8}"))
+			require.Equal(t, hex.EncodeToString(hash[:]), inc.Manifest.Files[filepath.Join(root, "b", "d.ndjson")].Hash)
+
+			// nothing has changed, so no file is opened
+			total, progress = collectIncremental(t, fileSystem, root, &inc)
+			require.EqualValues(t, 31, total)
+			require.EqualValues(t, 0, progress.FilesDecoded)
+			require.EqualValues(t, 4, progress.FilesReused)
+			require.Empty(t, fileSystem.reset())
+
+			// c.json is changed, f.jsonl is removed, g.json is new, a.json is touched without changes
+			require.NoError(t, os.WriteFile(filepath.Join(root, "b", "c.json"), []byte(`{"data": 32}`), 0o600))
+			require.NoError(t, os.Remove(filepath.Join(root, "b", "e", "f.jsonl")))
+			require.NoError(t, os.WriteFile(filepath.Join(root, "g.json"), []byte(`{"data": 64}`), 0o600))
+			touched := time.Now().Add(time.Hour)
+			require.NoError(t, os.Chtimes(filepath.Join(root, "a.json"), touched, touched))
+
+			total, progress = collectIncremental(t, fileSystem, root, &inc)
+			require.EqualValues(t, 1+32+4+8+64, total)
+			require.EqualValues(t, 2, progress.FilesDecoded) // values of touched a.json aren't used
+			require.EqualValues(t, 2, progress.FilesReused)
+			require.Equal(t, map[string]int{"a.json": 1, "c.json": 1, "g.json": 1}, fileSystem.reset())
+			require.Len(t, inc.Manifest.Files, 4)
+			require.True(t, inc.Manifest.Files[filepath.Join(root, "a.json")].ModTime.Equal(touched))
+
+			// manifest survives writing to disk
+			buf := new(bytes.Buffer)
+			require.NoError(t, inc.Manifest.Write(buf))
+			inc.Manifest, _ = ReadManifest(buf)
+			total, progress = collectIncremental(t, fileSystem, root, &inc)
+			require.EqualValues(t, 109, total)
+			require.EqualValues(t, 4, progress.FilesReused)
+			require.Empty(t, fileSystem.reset())
+		})
+	}
+}
+
+func TestIncrementalKeepsManifestOnFailure(t *testing.T) {
+	root := writeFiles(t, map[string][]byte{
+		"a.json": []byte(`{"data": 1}`),
+	})
+	inc := Incremental[TestAccumulator]{Manifest: NewManifest(), Codec: GobCodec[TestAccumulator](), Inverse: subtract}
+	total, _ := collectIncremental(t, fs.NewOsFileSystem(), root, &inc)
+	require.EqualValues(t, 1, total)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "broken.json"), []byte(`{`), 0o600))
+	_, manifest, err := New[TestType, TestAccumulator]().CollectIncremental(context.Background(), fs.NewOsFileSystem(), root,
+		Configuration{SearchWorkers: 1, FileWorkers: 1, AccumulatorWorkers: 1}, sum, sumCombiner, inc)
+	require.ErrorContains(t, err, "broken.json")
+	require.Same(t, inc.Manifest, manifest)
+
+	// skipped file isn't remembered, so it is tried again by the next run
+	result, manifest, err := New[TestType, TestAccumulator]().CollectIncremental(context.Background(), fs.NewOsFileSystem(), root,
+		Configuration{SearchWorkers: 1, FileWorkers: 1, AccumulatorWorkers: 1, ErrorPolicy: SkipErrors}, sum, sumCombiner, inc)
+	require.ErrorContains(t, err, "broken.json")
+	require.EqualValues(t, 1, result.Sum)
+	require.Len(t, manifest.Files, 1)
+}
+
+func TestIncrementalRequirements(t *testing.T) {
+	c := New[TestType, TestAccumulator]()
+	conf := Configuration{SearchWorkers: 1, FileWorkers: 1, AccumulatorWorkers: 1}
+
+	_, _, err := c.CollectIncremental(context.Background(), fs.NewOsFileSystem(), t.TempDir(), conf, sum, sumCombiner,
+		Incremental[TestAccumulator]{Codec: JSONCodec[TestAccumulator]()})
+	require.ErrorIs(t, err, ErrNoManifest)
+
+	_, err = ReadManifest(bytes.NewBufferString("{"))
+	require.Error(t, err)
+}
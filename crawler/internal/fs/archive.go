@@ -0,0 +1,632 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxArchiveEntries is the limit of entries of a single archive used by default
+	DefaultMaxArchiveEntries = 100_000
+	// DefaultMaxArchiveSize is the limit of the total decompressed size of a single archive used by default
+	DefaultMaxArchiveSize = 1 << 30
+)
+
+var (
+	_ FileSystem = (*archiveFileSystem)(nil)
+
+	// ErrTooManyEntries is returned, when the archive has more entries than ArchiveLimits.MaxEntries
+	ErrTooManyEntries = errors.New("archive has too many entries")
+	// ErrArchiveTooLarge is returned, when the total decompressed size of the archive exceeds ArchiveLimits.MaxSize
+	ErrArchiveTooLarge = errors.New("archive is too large")
+)
+
+// ArchiveLimits protects archiveFileSystem against archive bombs. Limits are checked when the archive
+// is read for the first time, so an archive exceeding them fails as a whole.
+// MaxSize also bounds the memory taken by zip archive, which is read into memory, because its file
+// can't be read at any offset, and the temporary file of tar archive decompressed for indexing:
+// the copy includes headers and skipped entries, so it mustn't be larger than MaxSize as a whole.
+type ArchiveLimits struct {
+	MaxEntries int    // maximum count of entries of a single archive, zero means DefaultMaxArchiveEntries
+	MaxSize    int64  // maximum total decompressed size of files of a single archive, zero means DefaultMaxArchiveSize
+	SpoolDir   string // directory of temporary files of tar archives, empty means os.TempDir
+}
+
+// Format of the archive detected by its extension
+type archiveKind int
+
+const (
+	zipArchive   archiveKind = iota // .zip
+	tarArchive                      // .tar
+	tarGzArchive                    // .tar.gz and .tgz
+)
+
+// archiveFileSystem wraps another file system and shows .zip, .tar, .tar.gz and .tgz files as directories,
+// so that their entries are crawled like ordinary files. The entry of the archive is named by joining
+// the path to the archive and the path of the entry, for example "data/bundle.zip/2024/a.json".
+// Archives inside archives and links inside archives aren't supported, they are shown as files and skipped
+// respectively. Open of the archive itself returns its raw contents.
+// The index of entries of every archive is built once and is rebuilt, when the size or modification time
+// of the archive has changed, so the archive is described by the wrapped file system on every call.
+// Entries of zip archives and of uncompressed tar archives are read directly, if files of the wrapped
+// file system implement io.ReaderAt. Otherwise tar archives are decompressed once into a temporary file,
+// while they are indexed, and their entries are read from it, so crawling the archive takes linear time. Opened archives and temporary files are kept until Close.
+// All methods are thread-safe.
+type archiveFileSystem struct {
+	fileSystem FileSystem
+	limits     ArchiveLimits
+
+	mu       sync.Mutex          // guards archives and retired
+	archives map[string]*archive // indexes of archives by their paths
+	retired  []*archive          // replaced indexes, their files are closed by Close
+}
+
+// The index of the single archive
+type archive struct {
+	path    string
+	kind    archiveKind
+	size    int64     // size of the archive, when the index has been built
+	modTime time.Time // modification time of the archive, when the index has been built
+
+	once     sync.Once
+	err      error                    // error of building of the index
+	file     File                     // the archive or its temporary copy kept open, when its entries are read directly
+	readerAt io.ReaderAt              // contents of the archive, nil means that entries are found by reading
+	entries  map[string]*archiveEntry // entries by cleaned slash separated paths, the root of the archive is "."
+}
+
+// File or directory of the archive
+type archiveEntry struct {
+	info     *entryInfo
+	children []os.DirEntry // sorted entries of the directory
+	zipFile  *zip.File     // entry of zip archive
+	ordinal  int           // number of the header of tar archive
+	offset   int64         // offset of contents in uncompressed tar archive, it is -1, if the archive must be read
+}
+
+// entryInfo describes the entry of the archive or the archive shown as the directory
+type entryInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// Archive file shown as the directory by ReadDir
+type archiveDirEntry struct {
+	os.DirEntry
+}
+
+// Opened file of the archive, errors of reading are reported with the path of the entry
+type entryFile struct {
+	io.Reader
+	name    string
+	closers []io.Closer // closed in the order
+}
+
+// NewArchiveFileSystem creates a new instance of archiveFileSystem, which reads files and archives
+// from fileSystem. Zero limits are replaced by default ones. Temporary copies of tar archives are created
+// in limits.SpoolDir, which must exist, or in os.TempDir, if it is empty.
+func NewArchiveFileSystem(fileSystem FileSystem, limits ArchiveLimits) *archiveFileSystem {
+	if limits.MaxEntries == 0 {
+		limits.MaxEntries = DefaultMaxArchiveEntries
+	}
+	if limits.MaxSize == 0 {
+		limits.MaxSize = DefaultMaxArchiveSize
+	}
+	return &archiveFileSystem{fileSystem: fileSystem, limits: limits, archives: make(map[string]*archive)}
+}
+
+// Open opens the file specified by its name. The entry of the archive is found in the index, which is rebuilt,
+// if the archive has changed since it was indexed. The entry can't be read after Close.
+func (a *archiveFileSystem) Open(name string) (File, error) {
+	arc, member, err := a.resolve("open", name, false)
+	if err != nil {
+		return nil, err
+	}
+	if arc == nil {
+		return a.fileSystem.Open(name)
+	}
+	entry, ok := arc.entries[member]
+	if !ok {
+		return nil, &iofs.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	if entry.info.IsDir() {
+		return &memFile{Reader: bytes.NewReader(nil), name: name, isDir: true}, nil
+	}
+	file, err := arc.open(a.fileSystem, entry, name)
+	if err != nil {
+		return nil, &iofs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return file, nil
+}
+
+// ReadDir reads the contents of the directory or the archive specified by the name. Archives are shown
+// as directories.
+func (a *archiveFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	arc, member, err := a.resolve("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if arc == nil {
+		entries, err := a.fileSystem.ReadDir(name)
+		for i, entry := range entries {
+			if _, ok := archiveKindOf(entry.Name()); ok && entry.Type().IsRegular() {
+				entries[i] = archiveDirEntry{entry}
+			}
+		}
+		return entries, err
+	}
+	entry, ok := arc.entries[member]
+	if !ok {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: os.ErrNotExist}
+	}
+	if !entry.info.IsDir() {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+	return slices.Clone(entry.children), nil
+}
+
+// Stat returns information about the file specified by the name, archives are described as directories.
+func (a *archiveFileSystem) Stat(name string) (os.FileInfo, error) {
+	return a.stat("stat", name, a.fileSystem.Stat)
+}
+
+// Lstat returns information about the file specified by the name without following the symbolic link,
+// archives are described as directories.
+func (a *archiveFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return a.stat("lstat", name, a.fileSystem.Lstat)
+}
+
+// Join joins any number of path elements into a single path using Join of the wrapped file system.
+func (a *archiveFileSystem) Join(elem ...string) string {
+	return a.fileSystem.Join(elem...)
+}
+
+// Close closes opened archives, the file system can be used after it, archives are opened again.
+func (a *archiveFileSystem) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var errs []error
+	for _, arc := range a.retired {
+		errs = append(errs, arc.close())
+	}
+	for _, arc := range a.archives {
+		errs = append(errs, arc.close())
+	}
+	a.archives, a.retired = make(map[string]*archive), nil
+	return errors.Join(errs...)
+}
+
+// Function describes the file by the method of the wrapped file system or by the index of the archive
+func (a *archiveFileSystem) stat(op string, name string, stat func(name string) (os.FileInfo, error)) (os.FileInfo, error) {
+	arc, member, err := a.resolve(op, name, false)
+	if err != nil {
+		return nil, err
+	}
+	if arc == nil {
+		info, err := stat(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := archiveKindOf(name); ok && info.Mode().IsRegular() {
+			return asDir(info), nil
+		}
+		return info, nil
+	}
+	entry, ok := arc.entries[member]
+	if !ok {
+		return nil, &iofs.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+	return entry.info, nil
+}
+
+// Function finds the archive, which contains the file specified by the name, and returns the index of the archive
+// and the path of the entry in it. Nil archive means that the file isn't inside an archive or that the name
+// is the archive itself and root isn't set
+func (a *archiveFileSystem) resolve(op string, name string, root bool) (*archive, string, error) {
+	slashed := filepath.ToSlash(name) // it has the same length, so indexes are the same
+	for i := 1; i <= len(slashed); i++ {
+		if i < len(slashed) && slashed[i] != '/' {
+			continue
+		}
+		kind, ok := archiveKindOf(slashed[:i])
+		if !ok {
+			continue
+		}
+		member := clean(slashed[i:])
+		info, err := a.fileSystem.Stat(name[:i])
+		if err != nil || !info.Mode().IsRegular() { // it is a directory with the name of an archive
+			continue
+		}
+		if member == "." && !root { // the archive is described without its index
+			return nil, "", nil
+		}
+		arc, err := a.archive(name[:i], kind, info)
+		if err != nil {
+			return nil, "", &iofs.PathError{Op: op, Path: name, Err: err}
+		}
+		return arc, member, nil
+	}
+	return nil, "", nil
+}
+
+// Function returns the index of the archive, the index is built, if it doesn't exist or is outdated
+func (a *archiveFileSystem) archive(archivePath string, kind archiveKind, info os.FileInfo) (*archive, error) {
+	a.mu.Lock()
+	arc, ok := a.archives[archivePath]
+	if !ok || arc.size != info.Size() || !arc.modTime.Equal(info.ModTime()) {
+		if ok {
+			a.retired = append(a.retired, arc) // it may be read by opened files
+		}
+		arc = &archive{path: archivePath, kind: kind, size: info.Size(), modTime: info.ModTime()}
+		a.archives[archivePath] = arc
+	}
+	a.mu.Unlock()
+
+	if err := a.build(arc); err != nil {
+		return nil, err
+	}
+	return arc, nil
+}
+
+// Function builds the index of the archive, if it isn't built yet, or waits for building by another call
+func (a *archiveFileSystem) build(arc *archive) error {
+	arc.once.Do(func() {
+		arc.err = arc.load(a.fileSystem, a.limits)
+	})
+	if arc.err != nil { // the failed index isn't cached, so the next call tries again
+		a.mu.Lock()
+		if a.archives[arc.path] == arc {
+			delete(a.archives, arc.path)
+		}
+		a.mu.Unlock()
+		return arc.err
+	}
+	return nil
+}
+
+// Function builds the index of the archive
+func (arc *archive) load(fileSystem FileSystem, limits ArchiveLimits) error {
+	file, err := fileSystem.Open(arc.path)
+	if err != nil {
+		return err
+	}
+	arc.entries = map[string]*archiveEntry{".": {info: &entryInfo{name: path.Base(filepath.ToSlash(arc.path)), mode: os.ModeDir | 0o555, modTime: arc.modTime}}}
+	if arc.kind == zipArchive {
+		err = arc.loadZip(file, limits)
+	} else {
+		err = arc.loadTar(file, limits)
+	}
+	if err != nil || arc.file != file { // the file isn't needed to read entries
+		err = errors.Join(err, file.Close())
+	}
+	if err != nil {
+		arc.file = nil
+		return err
+	}
+
+	for name, entry := range arc.entries {
+		if name != "." {
+			parent := arc.entries[path.Dir(name)]
+			parent.children = append(parent.children, iofs.FileInfoToDirEntry(entry.info))
+		}
+	}
+	for _, entry := range arc.entries {
+		slices.SortFunc(entry.children, func(a, b os.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	}
+	return nil
+}
+
+// Function indexes entries of zip archive, the archive is read into memory, if it can't be read at any offset.
+// The archive read into memory mustn't be larger than limits.MaxSize
+func (arc *archive) loadZip(file File, limits ArchiveLimits) error {
+	readerAt, ok := file.(io.ReaderAt)
+	size := arc.size
+	if ok {
+		arc.file = file
+	} else {
+		data, err := io.ReadAll(io.LimitReader(file, limits.MaxSize+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) > limits.MaxSize {
+			return fmt.Errorf("%w: more than %d bytes", ErrArchiveTooLarge, limits.MaxSize)
+		}
+		readerAt, size = bytes.NewReader(data), int64(len(data))
+	}
+	r, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return err
+	}
+	if len(r.File) > limits.MaxEntries {
+		return fmt.Errorf("%w: more than %d", ErrTooManyEntries, limits.MaxEntries)
+	}
+
+	var total uint64 // decompressed size, zip.File checks that contents don't exceed it
+	for _, f := range r.File {
+		switch {
+		case f.Mode().IsDir():
+			arc.dir(f.Name, f.Modified)
+		case f.Mode().IsRegular():
+			if total += f.UncompressedSize64; total > uint64(limits.MaxSize) {
+				return fmt.Errorf("%w: more than %d bytes", ErrArchiveTooLarge, limits.MaxSize)
+			}
+			arc.add(f.Name, &archiveEntry{zipFile: f}, int64(f.UncompressedSize64), f.Mode(), f.Modified)
+		}
+	}
+	arc.readerAt = readerAt
+	return nil
+}
+
+// Function indexes entries of tar archive and remembers offsets of their contents. If the archive is compressed
+// or can't be read at any offset, the decompressed archive is copied into the temporary file, while it is read,
+// and offsets point into the copy
+func (arc *archive) loadTar(file File, limits ArchiveLimits) (err error) {
+	var r io.Reader = file
+	if arc.kind == tarGzArchive {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = gz.Close() // it doesn't close the file and its errors are reported by reading
+		}()
+		r = gz
+	}
+	readerAt, direct := file.(io.ReaderAt)
+	direct = direct && arc.kind == tarArchive
+	var spool *tempFile // the copy of the decompressed archive
+	if !direct {
+		if spool, err = newTempFile(limits.SpoolDir); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				err = errors.Join(err, spool.Close())
+			}
+		}()
+		// tar.Reader reads all bytes up to the last header, so offsets are the same
+		r = io.TeeReader(r, &limitedWriter{w: spool, n: limits.MaxSize})
+	}
+	counter := &countingReader{r: r}
+	tr := tar.NewReader(counter)
+
+	var total int64 // decompressed size, tar.Reader doesn't read beyond sizes of headers
+	for ordinal := 0; ; ordinal++ {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if ordinal >= limits.MaxEntries {
+			return fmt.Errorf("%w: more than %d", ErrTooManyEntries, limits.MaxEntries)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			arc.dir(hdr.Name, hdr.ModTime)
+		case tar.TypeReg:
+			if total += hdr.Size; total > limits.MaxSize {
+				return fmt.Errorf("%w: more than %d bytes", ErrArchiveTooLarge, limits.MaxSize)
+			}
+			entry := &archiveEntry{ordinal: ordinal, offset: -1}
+			if !sparse(hdr) {
+				entry.offset = counter.n // contents follow the header
+			}
+			arc.add(hdr.Name, entry, hdr.Size, hdr.FileInfo().Mode(), hdr.ModTime)
+		}
+	}
+	if direct {
+		arc.file, arc.readerAt = file, readerAt
+	} else { // Next reads contents of the last file, before it reports the end, so they are copied too
+		arc.file, arc.readerAt = spool, spool
+	}
+	return nil
+}
+
+// Function adds the file to the index together with its parent directories
+func (arc *archive) add(name string, entry *archiveEntry, size int64, mode os.FileMode, modTime time.Time) {
+	name = clean(name)
+	if name == "." {
+		return
+	}
+	arc.dir(path.Dir(name), modTime)
+	entry.info = &entryInfo{name: path.Base(name), size: size, mode: mode.Perm(), modTime: modTime}
+	arc.entries[name] = entry
+}
+
+// Function adds the directory to the index together with its parents, the directory replaces the file
+// with the same name
+func (arc *archive) dir(name string, modTime time.Time) {
+	for name = clean(name); name != "."; name = path.Dir(name) {
+		if entry, ok := arc.entries[name]; ok && entry.info.IsDir() {
+			return
+		}
+		arc.entries[name] = &archiveEntry{info: &entryInfo{name: path.Base(name), mode: os.ModeDir | 0o555, modTime: modTime}}
+	}
+}
+
+// Function opens the file of the archive
+func (arc *archive) open(fileSystem FileSystem, entry *archiveEntry, name string) (File, error) {
+	if entry.zipFile != nil {
+		rc, err := entry.zipFile.Open()
+		if err != nil {
+			return nil, err
+		}
+		return &entryFile{Reader: rc, name: name, closers: []io.Closer{rc}}, nil
+	}
+	if entry.offset >= 0 {
+		return &entryFile{Reader: io.NewSectionReader(arc.readerAt, entry.offset, entry.info.size), name: name}, nil
+	}
+
+	file, err := fileSystem.Open(arc.path) // the archive is read from the beginning up to the entry
+	if err != nil {
+		return nil, err
+	}
+	result := &entryFile{Reader: file, name: name, closers: []io.Closer{file}}
+	if arc.kind == tarGzArchive {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, errors.Join(err, result.Close())
+		}
+		result.closers = append([]io.Closer{gz}, result.closers...)
+		result.Reader = gz
+	}
+	tr := tar.NewReader(result.Reader)
+	for i := 0; i <= entry.ordinal; i++ {
+		if _, err := tr.Next(); err != nil {
+			if errors.Is(err, io.EOF) { // the archive has been changed after indexing
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, errors.Join(err, result.Close())
+		}
+	}
+	result.Reader = tr
+	return result, nil
+}
+
+// Function closes the archive kept open, the index can't be built after it
+func (arc *archive) close() error {
+	arc.once.Do(func() { // waits for building of the index
+		arc.err = os.ErrClosed
+	})
+	if arc.file == nil {
+		return nil
+	}
+	return arc.file.Close()
+}
+
+// Function returns the format of the archive by the extension of its name
+func archiveKindOf(name string) (archiveKind, bool) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return zipArchive, true
+	case strings.HasSuffix(name, ".tar"):
+		return tarArchive, true
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return tarGzArchive, true
+	default:
+		return 0, false
+	}
+}
+
+// Function reports whether contents of the file of tar archive are stored as sparse, so they don't follow the header
+func sparse(hdr *tar.Header) bool {
+	for key := range hdr.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// Function describes the archive as the directory
+func asDir(info os.FileInfo) *entryInfo {
+	return &entryInfo{name: info.Name(), mode: os.ModeDir | info.Mode().Perm(), modTime: info.ModTime()}
+}
+
+func (e *entryInfo) Name() string       { return e.name }
+func (e *entryInfo) Size() int64        { return e.size }
+func (e *entryInfo) Mode() os.FileMode  { return e.mode }
+func (e *entryInfo) ModTime() time.Time { return e.modTime }
+func (e *entryInfo) IsDir() bool        { return e.mode.IsDir() }
+func (e *entryInfo) Sys() any           { return nil }
+
+// IsDir reports that the archive is shown as the directory
+func (e archiveDirEntry) IsDir() bool {
+	return true
+}
+
+// Type reports that the archive is shown as the directory
+func (e archiveDirEntry) Type() os.FileMode {
+	return os.ModeDir
+}
+
+// Info describes the archive as the directory
+func (e archiveDirEntry) Info() (os.FileInfo, error) {
+	info, err := e.DirEntry.Info()
+	if err != nil {
+		return nil, err
+	}
+	return asDir(info), nil
+}
+
+// Read reads contents of the entry, errors contain the path of the entry
+func (f *entryFile) Read(p []byte) (int, error) {
+	n, err := f.Reader.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = &iofs.PathError{Op: "read", Path: f.name, Err: err}
+	}
+	return n, err
+}
+
+// Close closes the entry and the archive opened for it
+func (f *entryFile) Close() error {
+	var errs []error
+	for _, c := range f.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Reader, which counts read bytes
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Writer, which fails with ErrArchiveTooLarge, when more than n bytes are written
+type limitedWriter struct {
+	w io.Writer
+	n int64 // bytes left
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.n {
+		return 0, fmt.Errorf("%w: temporary copy is more than %d bytes", ErrArchiveTooLarge, l.n)
+	}
+	n, err := l.w.Write(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// Temporary file, which is removed, when it is closed
+type tempFile struct {
+	*os.File
+}
+
+// Function creates the temporary file in the directory, empty directory means the default directory for temporary files
+func newTempFile(dir string) (*tempFile, error) {
+	f, err := os.CreateTemp(dir, "archive-*.tar")
+	if err != nil {
+		return nil, err
+	}
+	return &tempFile{f}, nil
+}
+
+// Close closes and removes the file
+func (t *tempFile) Close() error {
+	return errors.Join(t.File.Close(), os.Remove(t.Name()))
+}
@@ -0,0 +1,308 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Entries of archives of tests, names ending with a slash are directories
+var archiveEntries = []struct{ name, data string }{
+	{"a/", ""},
+	{"a/b.json", "1"},
+	{"a/c/d.json", "22"}, // its parent isn't listed
+	{"./e.json", "333"},
+}
+
+func zipOf(t *testing.T) []byte {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, e := range archiveEntries {
+		f, err := w.Create(e.name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(e.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func tarOf(t *testing.T, compressed bool) []byte {
+	buf := new(bytes.Buffer)
+	var out io.Writer = buf
+	gz := gzip.NewWriter(buf)
+	if compressed {
+		out = gz
+	}
+	w := tar.NewWriter(out)
+	for _, e := range archiveEntries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.data)), ModTime: time.Now(), Typeflag: tar.TypeReg}
+		if e.data == "" {
+			hdr.Typeflag = tar.TypeDir
+		}
+		require.NoError(t, w.WriteHeader(hdr))
+		_, err := w.Write([]byte(e.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.WriteHeader(&tar.Header{Name: "link", Linkname: "e.json", Typeflag: tar.TypeSymlink}))
+	require.NoError(t, w.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// File system, which hides io.ReaderAt of files, so that entries of archives are found by reading
+type sequentialFileSystem struct {
+	FileSystem
+}
+
+func (s sequentialFileSystem) Open(name string) (File, error) {
+	file, err := s.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ File }{file}, nil
+}
+
+// File system, which counts calls of Open and Stat
+type countingFileSystem struct {
+	FileSystem
+
+	mu           sync.Mutex
+	opens, stats int
+}
+
+func (c *countingFileSystem) Open(name string) (File, error) {
+	c.mu.Lock()
+	c.opens++
+	c.mu.Unlock()
+	return c.FileSystem.Open(name)
+}
+
+func (c *countingFileSystem) Stat(name string) (os.FileInfo, error) {
+	c.mu.Lock()
+	c.stats++
+	c.mu.Unlock()
+	return c.FileSystem.Stat(name)
+}
+
+func TestArchiveFileSystem(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "x.zip"), zipOf(t), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "y.tar"), tarOf(t, false), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "z.tar.gz"), tarOf(t, true), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(root, "dir.zip"), 0o700)) // directory isn't an archive
+
+	for name, inner := range map[string]FileSystem{"direct": NewOsFileSystem(), "sequential": sequentialFileSystem{NewOsFileSystem()}} {
+		t.Run(name, func(t *testing.T) {
+			a := NewArchiveFileSystem(inner, ArchiveLimits{})
+			t.Cleanup(func() {
+				require.NoError(t, a.Close())
+			})
+
+			entries, err := a.ReadDir(root)
+			require.NoError(t, err)
+			require.Len(t, entries, 4)
+			for _, entry := range entries {
+				require.True(t, entry.IsDir(), entry.Name())
+				info, err := entry.Info()
+				require.NoError(t, err)
+				require.True(t, info.IsDir())
+			}
+
+			for _, archive := range []string{"x.zip", "y.tar", "z.tar.gz"} {
+				archivePath := filepath.Join(root, archive)
+				info, err := a.Stat(archivePath)
+				require.NoError(t, err)
+				require.True(t, info.IsDir())
+				require.Equal(t, archive, info.Name())
+
+				require.Equal(t, []string{"a", "e.json"}, names(t, a, archivePath))
+				require.Equal(t, []string{"b.json", "c"}, names(t, a, a.Join(archivePath, "a")))
+				require.Equal(t, "1", readFile(t, a, a.Join(archivePath, "a", "b.json")))
+				require.Equal(t, "22", readFile(t, a, a.Join(archivePath, "a", "c", "d.json")))
+				require.Equal(t, "333", readFile(t, a, a.Join(archivePath, "e.json")))
+				require.Equal(t, []string{archivePath, a.Join(archivePath, "a"), a.Join(archivePath, "a", "b.json"),
+					a.Join(archivePath, "a", "c"), a.Join(archivePath, "a", "c", "d.json"), a.Join(archivePath, "e.json")},
+					walk(t, a, archivePath))
+
+				info, err = a.Lstat(a.Join(archivePath, "a", "c", "d.json"))
+				require.NoError(t, err)
+				require.EqualValues(t, 2, info.Size())
+				require.False(t, info.IsDir())
+
+				_, err = a.Open(a.Join(archivePath, "missing.json"))
+				require.ErrorIs(t, err, os.ErrNotExist)
+				require.ErrorContains(t, err, a.Join(archive, "missing.json"))
+				_, err = a.ReadDir(a.Join(archivePath, "e.json"))
+				require.Error(t, err)
+			}
+
+			raw, err := os.ReadFile(filepath.Join(root, "x.zip")) // the archive itself is opened as the file
+			require.NoError(t, err)
+			require.Equal(t, string(raw), readFile(t, a, filepath.Join(root, "x.zip")))
+		})
+	}
+}
+
+func TestArchiveFileSystemInMemory(t *testing.T) {
+	m := NewMemFileSystem()
+	require.NoError(t, m.WriteFile("data/x.zip", zipOf(t)))
+	require.NoError(t, m.WriteFile("data/z.tgz", tarOf(t, true)))
+	a := NewArchiveFileSystem(m, ArchiveLimits{})
+
+	require.Equal(t, []string{"data", "data/x.zip", "data/x.zip/a", "data/x.zip/a/b.json", "data/x.zip/a/c",
+		"data/x.zip/a/c/d.json", "data/x.zip/e.json", "data/z.tgz", "data/z.tgz/a", "data/z.tgz/a/b.json",
+		"data/z.tgz/a/c", "data/z.tgz/a/c/d.json", "data/z.tgz/e.json"}, walk(t, a, "data"))
+
+	// changed archive is indexed again, even if it isn't listed
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	w := tar.NewWriter(gz)
+	require.NoError(t, w.WriteHeader(&tar.Header{Name: "e.json", Mode: 0o644, Size: 4}))
+	_, err := w.Write([]byte("4444"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, m.WriteFile("data/z.tgz", buf.Bytes()))
+	require.Equal(t, "4444", readFile(t, a, "data/z.tgz/e.json"))
+	_, err = a.Stat("data/z.tgz/a/b.json")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, m.WriteFile("data/x.zip", tarOf(t, false)))
+	_, err = a.ReadDir("data/x.zip")
+	require.ErrorIs(t, err, zip.ErrFormat)
+	require.ErrorContains(t, err, "data/x.zip")
+	require.NoError(t, a.Close())
+}
+
+func TestArchiveLimits(t *testing.T) {
+	m := NewMemFileSystem()
+	require.NoError(t, m.WriteFile("x.zip", zipOf(t)))
+	require.NoError(t, m.WriteFile("y.tar", tarOf(t, false)))
+
+	for _, archive := range []string{"x.zip", "y.tar"} {
+		_, err := NewArchiveFileSystem(m, ArchiveLimits{MaxEntries: 3}).ReadDir(archive)
+		require.ErrorIs(t, err, ErrTooManyEntries)
+
+		_, err = NewArchiveFileSystem(m, ArchiveLimits{MaxSize: 5}).Open(archive + "/e.json")
+		require.ErrorIs(t, err, ErrArchiveTooLarge)
+		require.ErrorContains(t, err, archive+"/e.json")
+
+		_, err = NewArchiveFileSystem(m, ArchiveLimits{MaxEntries: 5, MaxSize: 6}).Stat(archive + "/e.json")
+		require.NoError(t, err)
+	}
+}
+
+func TestArchiveSpoolLimit(t *testing.T) {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	w := tar.NewWriter(gz)
+	for i := range 10 { // headers take 512 bytes each, while files are empty
+		require.NoError(t, w.WriteHeader(&tar.Header{Name: strconv.Itoa(i) + ".json", Mode: 0o644}))
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, gz.Close())
+	m := NewMemFileSystem()
+	require.NoError(t, m.WriteFile("x.tgz", buf.Bytes()))
+	temp := t.TempDir()
+
+	_, err := NewArchiveFileSystem(m, ArchiveLimits{MaxSize: 4096, SpoolDir: temp}).ReadDir("x.tgz")
+	require.ErrorIs(t, err, ErrArchiveTooLarge)
+	require.ErrorContains(t, err, "x.tgz")
+	left, err := os.ReadDir(temp)
+	require.NoError(t, err)
+	require.Empty(t, left) // the copy of the failed archive is removed
+
+	a := NewArchiveFileSystem(m, ArchiveLimits{MaxSize: 16384, SpoolDir: temp})
+	entries, err := a.ReadDir("x.tgz")
+	require.NoError(t, err)
+	require.Len(t, entries, 10)
+	left, err = os.ReadDir(temp)
+	require.NoError(t, err)
+	require.Len(t, left, 1) // the copy is created in SpoolDir
+	require.NoError(t, a.Close())
+}
+
+func TestArchiveEntryReadError(t *testing.T) {
+	data := zipOf(t)
+	corrupted := bytes.Replace(data, []byte("333"), []byte("444"), 1) // checksum doesn't match
+	require.NotEqual(t, data, corrupted)
+	m := NewMemFileSystem()
+	require.NoError(t, m.WriteFile("x.zip", corrupted))
+	a := NewArchiveFileSystem(m, ArchiveLimits{})
+
+	file, err := a.Open("x.zip/e.json")
+	require.NoError(t, err)
+	_, err = io.ReadAll(file)
+	require.ErrorIs(t, err, zip.ErrChecksum)
+	var pathErr *iofs.PathError
+	require.ErrorAs(t, err, &pathErr)
+	require.Equal(t, "x.zip/e.json", pathErr.Path)
+	require.NoError(t, file.Close())
+}
+
+func TestArchiveReadIntoMemoryLimit(t *testing.T) {
+	m := NewMemFileSystem()
+	require.NoError(t, m.WriteFile("x.zip", zipOf(t)))
+	limits := ArchiveLimits{MaxSize: 6} // files fit, but the whole archive doesn't
+
+	_, err := NewArchiveFileSystem(sequentialFileSystem{m}, limits).ReadDir("x.zip")
+	require.ErrorIs(t, err, ErrArchiveTooLarge)
+	require.ErrorContains(t, err, "x.zip")
+
+	_, err = NewArchiveFileSystem(m, limits).ReadDir("x.zip") // the archive read at offsets isn't loaded
+	require.NoError(t, err)
+}
+
+func TestArchiveTarReadOnce(t *testing.T) {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	w := tar.NewWriter(gz)
+	const files = 100
+	for i := range files {
+		data := strconv.Itoa(i)
+		require.NoError(t, w.WriteHeader(&tar.Header{Name: strconv.Itoa(i) + ".json", Mode: 0o644, Size: int64(len(data))}))
+		_, err := w.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, gz.Close())
+	m := NewMemFileSystem()
+	require.NoError(t, m.WriteFile("x.tgz", buf.Bytes()))
+
+	for _, inner := range []FileSystem{m, sequentialFileSystem{m}} {
+		temp := t.TempDir()
+		counting := &countingFileSystem{FileSystem: inner}
+		a := NewArchiveFileSystem(counting, ArchiveLimits{SpoolDir: temp})
+
+		entries, err := a.ReadDir("x.tgz")
+		require.NoError(t, err)
+		require.Len(t, entries, files)
+		for _, entry := range entries {
+			name := a.Join("x.tgz", entry.Name())
+			_, err := a.Stat(name)
+			require.NoError(t, err)
+			require.Equal(t, strings.TrimSuffix(entry.Name(), ".json"), readFile(t, a, name))
+		}
+		require.Equal(t, 1, counting.opens) // the archive is decompressed once, while it is indexed
+		// the archive is only checked for changes by ReadDir, Stat and Open of every entry
+		require.Equal(t, 1+2*files, counting.stats)
+
+		require.NoError(t, a.Close())
+		left, err := os.ReadDir(temp)
+		require.NoError(t, err)
+		require.Empty(t, left) // the temporary copy is removed
+	}
+}
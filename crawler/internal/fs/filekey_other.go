@@ -0,0 +1,12 @@
+//go:build !unix
+
+package fs
+
+import (
+	"os"
+)
+
+// Function reports that files of the operating system have no known identity
+func osFileKey(os.FileInfo) (any, bool) {
+	return nil, false
+}
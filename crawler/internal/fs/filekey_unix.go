@@ -0,0 +1,23 @@
+//go:build unix
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// Identity of the file of the operating system
+type osKey struct {
+	dev uint64
+	ino uint64
+}
+
+// Function returns the device and the inode of the file described by os.Stat or os.Lstat
+func osFileKey(info os.FileInfo) (any, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, false
+	}
+	return osKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true // types of fields differ between systems
+}
@@ -9,7 +9,7 @@ import (
 //go:generate mockgen -destination=../../pkg/mocks/os_mock.go -package=mocks os DirEntry
 
 // FileSystem is an interface for file operations that provides essential methods
-// to open files, read directory contents, and join paths.
+// to open files, read directory contents, describe files, and join paths.
 // This interface guarantees thread-safe access to its methods, as multiple goroutines
 // may concurrently request filesystem resources. However, it does not guarantee
 // thread-safe access to the files themselves; concurrent access to a single file
@@ -34,6 +34,17 @@ type FileSystem interface {
 	// should be handled by the calling context.
 	ReadDir(name string) ([]os.DirEntry, error)
 
+	// Stat returns information about the file or the directory specified by its name.
+	// Symbolic links are followed, so the target of the link is described.
+	// This method is thread-safe and can be accessed concurrently by multiple goroutines.
+	Stat(name string) (os.FileInfo, error)
+
+	// Lstat returns information about the file or the directory specified by its name
+	// like Stat, but the symbolic link itself is described instead of its target.
+	// File systems without symbolic links may implement it as Stat.
+	// This method is thread-safe and can be accessed concurrently by multiple goroutines.
+	Lstat(name string) (os.FileInfo, error)
+
 	// Join joins any number of path elements into a single path. This method is
 	// thread-safe as it operates on string concatenation and does not directly access
 	// shared resources. It allows safe concurrent path generation by multiple goroutines.
@@ -47,3 +58,23 @@ type FileSystem interface {
 type File interface {
 	io.ReadCloser
 }
+
+// SameFile reports whether fi1 and fi2 describe the same file. Unlike os.SameFile, it also knows
+// files of the in-memory file system. Files of other file systems are never the same.
+func SameFile(fi1, fi2 os.FileInfo) bool {
+	if m1, ok := fi1.(*memFileInfo); ok {
+		m2, ok := fi2.(*memFileInfo)
+		return ok && m1.node == m2.node
+	}
+	return os.SameFile(fi1, fi2)
+}
+
+// FileKey returns the comparable identity of the file described by info, so that files can be kept in a set.
+// Infos of the same file have equal keys. It knows files of the in-memory file system and, on unix systems,
+// files of the operating system. For other files ok is false.
+func FileKey(info os.FileInfo) (key any, ok bool) {
+	if m, ok := info.(*memFileInfo); ok {
+		return m.node, true
+	}
+	return osFileKey(info)
+}
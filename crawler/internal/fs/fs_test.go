@@ -0,0 +1,258 @@
+package fs
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Function reads the whole file of the file system
+func readFile(t *testing.T, fileSystem FileSystem, name string) string {
+	file, err := fileSystem.Open(name)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, file.Close())
+	}()
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// Function returns names of entries of the directory
+func names(t *testing.T, fileSystem FileSystem, name string) []string {
+	entries, err := fileSystem.ReadDir(name)
+	require.NoError(t, err)
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry.Name())
+	}
+	return result
+}
+
+// Function returns paths of all files and directories of the tree in the order of walking
+func walk(t *testing.T, fileSystem FileSystem, root string) []string {
+	var visited []string
+	require.NoError(t, WalkDir(fileSystem, root, func(name string, _ iofs.DirEntry, err error) error {
+		visited = append(visited, name)
+		return err
+	}))
+	return visited
+}
+
+func TestMemFileSystem(t *testing.T) {
+	m := NewMemFileSystem()
+	require.NoError(t, m.WriteFile("a/b/c.json", []byte("1")))
+	require.NoError(t, m.WriteFile("/a/d.json", []byte("22")))
+	require.NoError(t, m.MkdirAll("a/empty"))
+
+	require.Equal(t, "1", readFile(t, m, "a/b/c.json"))
+	require.Equal(t, "22", readFile(t, m, "./a/../a/d.json"))
+	require.Equal(t, []string{"b", "d.json", "empty"}, names(t, m, "a"))
+	require.Equal(t, []string{"a"}, names(t, m, "/"))
+
+	info, err := m.Stat("a/d.json")
+	require.NoError(t, err)
+	require.Equal(t, "d.json", info.Name())
+	require.EqualValues(t, 2, info.Size())
+	require.True(t, info.Mode().IsRegular())
+
+	// opened file keeps the old contents
+	file, err := m.Open("a/d.json")
+	require.NoError(t, err)
+	require.NoError(t, m.WriteFile("a/d.json", []byte("333")))
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.Equal(t, "22", string(data))
+	require.Equal(t, "333", readFile(t, m, "a/d.json"))
+
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, m.Chtimes("a/d.json", modTime))
+	info, err = m.Stat("a/d.json")
+	require.NoError(t, err)
+	require.True(t, info.ModTime().Equal(modTime))
+
+	require.NoError(t, m.Remove("a/b"))
+	_, err = m.Stat("a/b/c.json")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.ErrorIs(t, m.Remove("a/b"), os.ErrNotExist)
+	require.Error(t, m.Remove("/"))
+
+	require.Error(t, m.WriteFile("a", nil))               // it is the directory
+	require.Error(t, m.WriteFile("a/d.json/e.json", nil)) // parent is the file
+	_, err = m.ReadDir("a/d.json")
+	require.Error(t, err)
+	file, err = m.Open("a")
+	require.NoError(t, err)
+	_, err = file.Read(make([]byte, 1))
+	require.Error(t, err)
+}
+
+func TestMemFileSystemSymlinks(t *testing.T) {
+	m := NewMemFileSystem()
+	require.NoError(t, m.WriteFile("dir/file.json", []byte("1")))
+	require.NoError(t, m.Symlink("dir", "relative"))
+	require.NoError(t, m.Symlink("/dir/file.json", "links/absolute"))
+	require.NoError(t, m.Symlink("../relative/file.json", "links/chain"))
+	require.NoError(t, m.Symlink("missing", "broken"))
+	require.NoError(t, m.Symlink("loop", "loop"))
+	require.ErrorIs(t, m.Symlink("dir", "relative"), os.ErrExist)
+
+	require.Equal(t, "1", readFile(t, m, "relative/file.json"))
+	require.Equal(t, "1", readFile(t, m, "links/absolute"))
+	require.Equal(t, "1", readFile(t, m, "links/chain"))
+	require.Equal(t, []string{"file.json"}, names(t, m, "relative"))
+
+	info, err := m.Lstat("relative")
+	require.NoError(t, err)
+	require.Equal(t, os.ModeSymlink, info.Mode().Type())
+	entries, err := m.ReadDir("/")
+	require.NoError(t, err)
+	require.Equal(t, "broken", entries[0].Name())
+	require.Equal(t, os.ModeSymlink, entries[0].Type()) // entries describe links themselves
+
+	target, err := m.Stat("relative")
+	require.NoError(t, err)
+	require.True(t, target.IsDir())
+	dir, err := m.Stat("dir")
+	require.NoError(t, err)
+	require.True(t, SameFile(dir, target))
+	require.False(t, SameFile(dir, info))
+	dirKey, ok := FileKey(dir)
+	require.True(t, ok)
+	targetKey, _ := FileKey(target)
+	linkKey, _ := FileKey(info)
+	require.Equal(t, dirKey, targetKey)
+	require.NotEqual(t, dirKey, linkKey)
+
+	_, err = m.Stat("broken")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = m.Stat("loop")
+	require.ErrorIs(t, err, errTooManyLinks)
+
+	require.NoError(t, m.WriteFile("links/absolute", []byte("2"))) // writing follows the link
+	require.Equal(t, "2", readFile(t, m, "dir/file.json"))
+	require.NoError(t, m.Remove("relative")) // removing doesn't follow the link
+	require.Equal(t, "2", readFile(t, m, "dir/file.json"))
+}
+
+func TestOverlayFileSystem(t *testing.T) {
+	lower := NewMemFileSystem()
+	require.NoError(t, lower.WriteFile("a/b.json", []byte("lower")))
+	require.NoError(t, lower.WriteFile("a/c.json", []byte("lower")))
+	require.NoError(t, lower.WriteFile("d/e.json", []byte("lower")))
+	upper := NewMemFileSystem()
+	require.NoError(t, upper.WriteFile("a/b.json", []byte("upper")))
+	require.NoError(t, upper.WriteFile("a/f.json", []byte("upper")))
+	o := NewOverlayFileSystem(upper, lower)
+
+	require.Equal(t, "upper", readFile(t, o, "a/b.json"))
+	require.Equal(t, "lower", readFile(t, o, "a/c.json"))
+	require.Equal(t, []string{"b.json", "c.json", "f.json"}, names(t, o, "a"))
+	require.Equal(t, []string{"a", "d"}, names(t, o, "."))
+
+	info, err := o.Stat("a/b.json")
+	require.NoError(t, err)
+	require.EqualValues(t, len("upper"), info.Size())
+	_, err = o.Lstat("d/e.json")
+	require.NoError(t, err)
+
+	_, err = o.Open("a/missing.json")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = o.ReadDir("missing")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.Equal(t, []string{".", "a", "a/b.json", "a/c.json", "a/f.json", "d", "d/e.json"}, walk(t, o, "."))
+}
+
+func TestOverlayFileHidesDirectory(t *testing.T) {
+	upper := NewMemFileSystem()
+	require.NoError(t, upper.WriteFile("a/b.json", []byte("upper")))
+	middle := NewMemFileSystem()
+	require.NoError(t, middle.WriteFile("a", []byte("middle")))
+	lower := NewMemFileSystem()
+	require.NoError(t, lower.WriteFile("a/c.json", []byte("lower")))
+
+	require.Equal(t, []string{"b.json"}, names(t, NewOverlayFileSystem(upper, middle, lower), "a"))
+	require.Equal(t, []string{".", "a", "a/b.json"}, walk(t, NewOverlayFileSystem(upper, middle, lower), "."))
+
+	o := NewOverlayFileSystem(middle, lower) // the uppermost file isn't a directory
+	_, err := o.ReadDir("a")
+	require.ErrorIs(t, err, errNotDir)
+	require.Equal(t, "middle", readFile(t, o, "a"))
+	require.Equal(t, []string{".", "a"}, walk(t, o, "."))
+}
+
+func TestOverlayOfOsFileSystem(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.json"), []byte("disk"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.json"), []byte("disk"), 0o600))
+	upper := NewMemFileSystem()
+	require.NoError(t, upper.WriteFile(filepath.ToSlash(filepath.Join(root, "b.json")), []byte("memory")))
+	o := NewOverlayFileSystem(upper, NewOsFileSystem())
+
+	require.Equal(t, "disk", readFile(t, o, filepath.Join(root, "a.json")))
+	require.Equal(t, "memory", readFile(t, o, filepath.Join(root, "b.json")))
+	require.Equal(t, []string{"a.json", "b.json"}, names(t, o, root))
+}
+
+func TestIOFileSystem(t *testing.T) {
+	mapFS := NewIOFileSystem(fstest.MapFS{
+		"a/b.json": {Data: []byte("1")},
+		"a/c.json": {Data: []byte("22")},
+		"d.json":   {Data: []byte("333")},
+	})
+	require.Equal(t, "22", readFile(t, mapFS, mapFS.Join("a", "c.json")))
+	require.Equal(t, []string{"a", "d.json"}, names(t, mapFS, "."))
+	info, err := mapFS.Lstat("d.json")
+	require.NoError(t, err)
+	require.EqualValues(t, 3, info.Size())
+	_, err = mapFS.Stat("missing")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.Equal(t, []string{".", "a", "a/b.json", "a/c.json", "d.json"}, walk(t, mapFS, "."))
+
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for name, data := range map[string]string{"x/y.json": "4", "z.json": "55"} {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	zipFS := NewIOFileSystem(r)
+	require.Equal(t, "4", readFile(t, zipFS, "x/y.json"))
+	require.Equal(t, []string{".", "x", "x/y.json", "z.json"}, walk(t, zipFS, "."))
+}
+
+func TestWalkDirSkip(t *testing.T) {
+	m := NewMemFileSystem()
+	for _, name := range []string{"a/b", "a/c/d", "e", "f/g"} {
+		require.NoError(t, m.WriteFile(name, nil))
+	}
+
+	var visited []string
+	require.NoError(t, WalkDir(m, ".", func(name string, entry iofs.DirEntry, _ error) error {
+		visited = append(visited, name)
+		switch name {
+		case "a/c":
+			return iofs.SkipDir
+		case "e":
+			return iofs.SkipAll
+		}
+		return nil
+	}))
+	require.Equal(t, []string{".", "a", "a/b", "a/c", "e"}, visited)
+
+	err := WalkDir(m, "missing", func(_ string, _ iofs.DirEntry, err error) error { return err })
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,59 @@
+package fs
+
+import (
+	iofs "io/fs"
+	"os"
+	"path"
+)
+
+var _ FileSystem = (*ioFileSystem)(nil)
+
+// ioFileSystem adapts io/fs.FS to the FileSystem interface, so that embed.FS, fstest.MapFS,
+// zip.Reader and other implementations of io/fs.FS can be crawled. Names are slash separated
+// and relative to the root of io/fs.FS, which is named ".".
+// The adapter is as thread-safe as the wrapped io/fs.FS, all implementations of the standard
+// library are safe for concurrent use.
+type ioFileSystem struct {
+	fsys iofs.FS
+}
+
+// Interface of io/fs.FS, which can describe symbolic links without following them
+type lstatFS interface {
+	iofs.FS
+	Lstat(name string) (iofs.FileInfo, error)
+}
+
+// NewIOFileSystem creates a new instance of ioFileSystem, which reads files from fsys.
+func NewIOFileSystem(fsys iofs.FS) *ioFileSystem {
+	return &ioFileSystem{fsys: fsys}
+}
+
+// Open opens the file specified by its name using the Open method of io/fs.FS.
+func (f *ioFileSystem) Open(name string) (File, error) {
+	return f.fsys.Open(name)
+}
+
+// ReadDir reads the contents of the directory specified by the name using io/fs.ReadDir,
+// entries are sorted by name.
+func (f *ioFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	return iofs.ReadDir(f.fsys, name)
+}
+
+// Stat returns information about the file specified by the name using io/fs.Stat.
+func (f *ioFileSystem) Stat(name string) (os.FileInfo, error) {
+	return iofs.Stat(f.fsys, name)
+}
+
+// Lstat returns information about the file specified by the name without following the symbolic
+// link, if io/fs.FS has the Lstat method. Otherwise it is the same as Stat.
+func (f *ioFileSystem) Lstat(name string) (os.FileInfo, error) {
+	if fsys, ok := f.fsys.(lstatFS); ok {
+		return fsys.Lstat(name)
+	}
+	return iofs.Stat(f.fsys, name)
+}
+
+// Join joins any number of path elements into a single slash separated path using path.Join.
+func (f *ioFileSystem) Join(elem ...string) string {
+	return path.Join(elem...)
+}
@@ -0,0 +1,315 @@
+package fs
+
+import (
+	"bytes"
+	"errors"
+	iofs "io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit of symbolic links followed while resolving a single name, the same as in Linux
+const maxLinkHops = 40
+
+var (
+	_ FileSystem = (*memFileSystem)(nil)
+
+	errIsDir         = errors.New("is a directory")
+	errNotDir        = errors.New("not a directory")
+	errTooManyLinks  = errors.New("too many levels of symbolic links")
+	errRootForbidden = errors.New("root can't be changed")
+)
+
+// memFileSystem is the writable in-memory implementation of the FileSystem interface, which is
+// useful for tests. Names are slash separated paths, the root is named "." or "/", and relative
+// names start from the root too. Contents of files are copied on writing, so files opened before
+// the change keep reading the old contents.
+// All methods are thread-safe.
+type memFileSystem struct {
+	mu   sync.RWMutex // guards all nodes
+	root *memNode
+}
+
+// File, directory or symbolic link of memFileSystem
+type memNode struct {
+	mode     os.FileMode // os.ModeDir, os.ModeSymlink or zero for regular files together with permissions
+	data     []byte      // contents of regular file, it is replaced on every write and never changed
+	target   string      // target of symbolic link
+	modTime  time.Time
+	children map[string]*memNode // entries of directory by names
+}
+
+// memFileInfo describes memNode at the moment of the request
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+	node    *memNode // identity of the file for SameFile
+}
+
+// Opened regular file or directory of memFileSystem
+type memFile struct {
+	*bytes.Reader
+	name  string
+	isDir bool
+}
+
+// NewMemFileSystem creates a new instance of memFileSystem with the empty root directory.
+func NewMemFileSystem() *memFileSystem {
+	return &memFileSystem{root: newDirNode()}
+}
+
+// Function creates the empty directory
+func newDirNode() *memNode {
+	return &memNode{mode: os.ModeDir | 0o755, modTime: time.Now(), children: make(map[string]*memNode)}
+}
+
+// Open opens the file specified by its name, symbolic links are followed. Directories can be opened,
+// but can't be read.
+func (m *memFileSystem) Open(name string) (File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	node, err := m.lookup("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{Reader: bytes.NewReader(node.data), name: name, isDir: node.mode.IsDir()}, nil
+}
+
+// ReadDir reads the contents of the directory specified by the name. Entries are sorted by name and
+// describe symbolic links themselves, like entries of os.ReadDir.
+func (m *memFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	node, err := m.lookup("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+	entries := make([]os.DirEntry, 0, len(node.children))
+	for childName, child := range node.children {
+		entries = append(entries, iofs.FileInfoToDirEntry(child.info(childName)))
+	}
+	slices.SortFunc(entries, func(a, b os.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+// Stat returns information about the file specified by the name, symbolic links are followed.
+func (m *memFileSystem) Stat(name string) (os.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	node, err := m.lookup("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return node.info(path.Base(name)), nil
+}
+
+// Lstat returns information about the file specified by the name, the symbolic link itself is described.
+func (m *memFileSystem) Lstat(name string) (os.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	node, err := m.lookup("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return node.info(path.Base(name)), nil
+}
+
+// Join joins any number of path elements into a single slash separated path using path.Join.
+func (m *memFileSystem) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+// WriteFile writes data to the file specified by the name, the file is created, if it doesn't exist.
+// Missing parent directories are created too. Symbolic links are followed.
+func (m *memFileSystem) WriteFile(name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node, err := m.lookup("write", name, true)
+	if errors.Is(err, os.ErrNotExist) {
+		var parent *memNode
+		if parent, err = m.mkdirAll("write", path.Dir(clean(name))); err != nil {
+			return err
+		}
+		if _, ok := parent.children[path.Base(clean(name))]; ok { // it is the broken symbolic link
+			return &iofs.PathError{Op: "write", Path: name, Err: os.ErrNotExist}
+		}
+		node = &memNode{mode: 0o644}
+		parent.children[path.Base(clean(name))] = node
+	} else if err != nil {
+		return err
+	}
+	if node.mode.IsDir() {
+		return &iofs.PathError{Op: "write", Path: name, Err: errIsDir}
+	}
+	node.data = bytes.Clone(data)
+	node.modTime = time.Now()
+	return nil
+}
+
+// MkdirAll creates the directory specified by the name together with missing parents.
+func (m *memFileSystem) MkdirAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := m.mkdirAll("mkdir", clean(name))
+	return err
+}
+
+// Symlink creates the symbolic link specified by the name, which points to target. Relative target
+// is resolved from the directory of the link. Missing parent directories are created.
+func (m *memFileSystem) Symlink(target, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if clean(name) == "." {
+		return &iofs.PathError{Op: "symlink", Path: name, Err: errRootForbidden}
+	}
+	parent, err := m.mkdirAll("symlink", path.Dir(clean(name)))
+	if err != nil {
+		return err
+	}
+	if _, ok := parent.children[path.Base(clean(name))]; ok {
+		return &iofs.PathError{Op: "symlink", Path: name, Err: os.ErrExist}
+	}
+	parent.children[path.Base(clean(name))] = &memNode{mode: os.ModeSymlink | 0o777, target: target, modTime: time.Now()}
+	return nil
+}
+
+// Remove removes the file, the symbolic link or the directory with all its contents specified by the name.
+// The last symbolic link isn't followed, so the link itself is removed.
+func (m *memFileSystem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if clean(name) == "." {
+		return &iofs.PathError{Op: "remove", Path: name, Err: errRootForbidden}
+	}
+	parent, err := m.lookup("remove", path.Dir(clean(name)), true)
+	if err != nil {
+		return err
+	}
+	if _, ok := parent.children[path.Base(clean(name))]; !ok || !parent.mode.IsDir() {
+		return &iofs.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	delete(parent.children, path.Base(clean(name)))
+	return nil
+}
+
+// Chtimes changes the modification time of the file specified by the name, symbolic links are followed.
+func (m *memFileSystem) Chtimes(name string, modTime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node, err := m.lookup("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	node.modTime = modTime
+	return nil
+}
+
+// Function returns the node specified by the name. The last symbolic link is followed, if follow is set,
+// symbolic links in the middle are always followed. Lock must be held by the caller
+func (m *memFileSystem) lookup(op string, name string, follow bool) (*memNode, error) {
+	parts := split(name)
+	dir, dirParts := m.root, make([]string, 0, len(parts)) // current directory and its path
+	for hops := 0; len(parts) > 0; {
+		if !dir.mode.IsDir() {
+			return nil, &iofs.PathError{Op: op, Path: name, Err: errNotDir}
+		}
+		child, ok := dir.children[parts[0]]
+		if !ok {
+			return nil, &iofs.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+		}
+		if child.mode&os.ModeSymlink != 0 && (len(parts) > 1 || follow) {
+			if hops++; hops > maxLinkHops {
+				return nil, &iofs.PathError{Op: op, Path: name, Err: errTooManyLinks}
+			}
+			target := child.target
+			if !strings.HasPrefix(target, "/") { // relative target starts from the directory of the link
+				target = path.Join(append(dirParts, target)...)
+			}
+			parts = append(split(target), parts[1:]...) // the rest of the name is resolved from the target
+			dir, dirParts = m.root, dirParts[:0]
+			continue
+		}
+		dir, dirParts, parts = child, append(dirParts, parts[0]), parts[1:]
+	}
+	return dir, nil
+}
+
+// Function returns the directory specified by the cleaned name and creates missing directories on the way.
+// Lock must be held by the caller
+func (m *memFileSystem) mkdirAll(op string, name string) (*memNode, error) {
+	node, err := m.lookup(op, name, true)
+	if err == nil {
+		if !node.mode.IsDir() {
+			return nil, &iofs.PathError{Op: op, Path: name, Err: errNotDir}
+		}
+		return node, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || name == "." {
+		return nil, err
+	}
+	parent, err := m.mkdirAll(op, path.Dir(name))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := parent.children[path.Base(name)]; ok { // it is the broken symbolic link
+		return nil, &iofs.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+	node = newDirNode()
+	parent.children[path.Base(name)] = node
+	return node, nil
+}
+
+// Function returns cleaned name without the leading slash, the root is "."
+func clean(name string) string {
+	name = path.Clean("/" + name)
+	if name == "/" {
+		return "."
+	}
+	return name[1:]
+}
+
+// Function splits the name into elements, the root has no elements
+func split(name string) []string {
+	name = clean(name)
+	if name == "." {
+		return nil
+	}
+	return strings.Split(name, "/")
+}
+
+// Function describes the node, lock must be held by the caller
+func (n *memNode) info(name string) *memFileInfo {
+	if name == "/" || name == "" {
+		name = "."
+	}
+	return &memFileInfo{name: name, size: int64(len(n.data)), mode: n.mode, modTime: n.modTime, node: n}
+}
+
+func (f *memFileInfo) Name() string       { return f.name }
+func (f *memFileInfo) Size() int64        { return f.size }
+func (f *memFileInfo) Mode() os.FileMode  { return f.mode }
+func (f *memFileInfo) ModTime() time.Time { return f.modTime }
+func (f *memFileInfo) IsDir() bool        { return f.mode.IsDir() }
+func (f *memFileInfo) Sys() any           { return nil }
+
+// Read reads contents of the regular file, directories can't be read
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.isDir {
+		return 0, &iofs.PathError{Op: "read", Path: f.name, Err: errIsDir}
+	}
+	return f.Reader.Read(p)
+}
+
+// Close does nothing, because contents of the file are kept in memory
+func (f *memFile) Close() error {
+	return nil
+}
@@ -32,6 +32,18 @@ func (o *osFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
 	return os.ReadDir(name)
 }
 
+// Stat returns information about the file specified by the name using os.Stat, so symbolic links
+// are followed.
+func (o *osFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// Lstat returns information about the file specified by the name using os.Lstat, so symbolic
+// links aren't followed.
+func (o *osFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(name)
+}
+
 // Join joins any number of path elements into a single path using filepath.Join from the
 // standard library.
 func (o *osFileSystem) Join(elem ...string) string {
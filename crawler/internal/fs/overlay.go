@@ -0,0 +1,97 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+)
+
+var _ FileSystem = (*overlayFileSystem)(nil)
+
+// overlayFileSystem stacks file systems on top of each other: a file of the upper layer hides files
+// with the same name in the lower layers, and directories of all layers are merged. For example,
+// the in-memory file system on top of the OS one replaces some files of the real tree in tests.
+// It is as thread-safe as its layers.
+type overlayFileSystem struct {
+	layers []FileSystem // the first layer is the top one
+}
+
+// NewOverlayFileSystem creates a new instance of overlayFileSystem, top is the uppermost layer and lower
+// layers follow it from the upper to the lowest one. Paths are built by Join of the top layer.
+func NewOverlayFileSystem(top FileSystem, lower ...FileSystem) *overlayFileSystem {
+	return &overlayFileSystem{layers: append([]FileSystem{top}, lower...)}
+}
+
+// Open opens the file of the uppermost layer, which has it.
+func (o *overlayFileSystem) Open(name string) (File, error) {
+	return firstLayer(o.layers, func(layer FileSystem) (File, error) { return layer.Open(name) })
+}
+
+// ReadDir merges the contents of the directory of all layers, which have it. The entry of the upper layer
+// hides entries with the same name of the lower layers. Layers below the layer, which has a file
+// with the name, aren't merged, because the file hides their directories. Entries are sorted by name.
+func (o *overlayFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	var merged []os.DirEntry
+	seen := make(map[string]bool) // names of merged entries
+	found := false                // whether some layer has the directory
+	var notExist error            // the error of the lowest layer, it is returned, if no layer has the directory
+	for _, layer := range o.layers {
+		entries, err := layer.ReadDir(name)
+		if errors.Is(err, os.ErrNotExist) {
+			notExist = err
+			continue
+		}
+		if err != nil {
+			if found && hasFile(layer, name) { // the file hides directories of lower layers like the upper directory hides it
+				break
+			}
+			return nil, err
+		}
+		found = true
+		for _, entry := range entries {
+			if !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				merged = append(merged, entry)
+			}
+		}
+	}
+	if !found {
+		return nil, notExist
+	}
+	slices.SortFunc(merged, func(a, b os.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return merged, nil
+}
+
+// Stat returns information about the file of the uppermost layer, which has it.
+func (o *overlayFileSystem) Stat(name string) (os.FileInfo, error) {
+	return firstLayer(o.layers, func(layer FileSystem) (os.FileInfo, error) { return layer.Stat(name) })
+}
+
+// Lstat returns information about the file of the uppermost layer, which has it, without following the symbolic link.
+func (o *overlayFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return firstLayer(o.layers, func(layer FileSystem) (os.FileInfo, error) { return layer.Lstat(name) })
+}
+
+// Join joins any number of path elements into a single path using Join of the top layer.
+func (o *overlayFileSystem) Join(elem ...string) string {
+	return o.layers[0].Join(elem...)
+}
+
+// Function returns the result of the uppermost layer, which doesn't fail with os.ErrNotExist
+func firstLayer[V any](layers []FileSystem, f func(layer FileSystem) (V, error)) (V, error) {
+	var v V
+	var err error
+	for _, layer := range layers {
+		if v, err = f(layer); !errors.Is(err, os.ErrNotExist) {
+			return v, err
+		}
+	}
+	return v, err
+}
+
+// Function reports whether the layer has a file, which isn't a directory, with the name
+func hasFile(layer FileSystem, name string) bool {
+	info, err := layer.Stat(name)
+	return err == nil && !info.IsDir()
+}
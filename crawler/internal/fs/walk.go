@@ -0,0 +1,57 @@
+package fs
+
+import (
+	"errors"
+	iofs "io/fs"
+	"slices"
+	"strings"
+)
+
+// WalkDir walks the tree of fileSystem rooted at root like io/fs.WalkDir: fn is called for every file
+// and directory including root, entries of every directory are visited in lexical order. Symbolic links
+// inside the tree aren't followed, root is followed. fn may return io/fs.SkipDir to skip the directory
+// and io/fs.SkipAll to stop walking.
+func WalkDir(fileSystem FileSystem, root string, fn iofs.WalkDirFunc) error {
+	info, err := fileSystem.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDir(fileSystem, root, iofs.FileInfoToDirEntry(info), fn)
+	}
+	if errors.Is(err, iofs.SkipDir) || errors.Is(err, iofs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// Function walks the directory entry recursively, io/fs.SkipDir is returned, when the rest of the parent
+// directory must be skipped
+func walkDir(fileSystem FileSystem, name string, entry iofs.DirEntry, fn iofs.WalkDirFunc) error {
+	if err := fn(name, entry, nil); err != nil || !entry.IsDir() {
+		if errors.Is(err, iofs.SkipDir) && entry.IsDir() { // the directory itself is skipped
+			err = nil
+		}
+		return err
+	}
+
+	entries, err := fileSystem.ReadDir(name)
+	if err != nil { // fn is called the second time to report the error
+		if err = fn(name, entry, err); err != nil {
+			if errors.Is(err, iofs.SkipDir) {
+				err = nil
+			}
+			return err
+		}
+	}
+	slices.SortFunc(entries, func(a, b iofs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	for _, child := range entries {
+		if err := walkDir(fileSystem, fileSystem.Join(name, child.Name()), child, fn); err != nil {
+			if errors.Is(err, iofs.SkipDir) { // the rest of the directory is skipped
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -56,6 +56,11 @@ type Pool[T, R any] interface {
 	// allowing exploration in a tree-like structure.
 	// The number of workers should be configured based on the workload, ensuring each worker
 	// independently processes assigned elements.
+	// Elements are streamed without collecting layers of the tree: a found child is passed to
+	// an idle worker or expanded depth-first by the worker, which has found it. So memory is
+	// bounded by workers * depth * children of a single parent rather than by the size of the tree.
+	// Children of a single parent are returned by searcher at once, so the parent with very many
+	// children is held in memory as a whole.
 	List(ctx context.Context, workers int, start T, searcher Searcher[T])
 }
 
@@ -110,120 +115,54 @@ func (p *poolImpl[T, R]) Accumulate(
 	return ans
 }
 
-// The function generator returns chan that returns values from inputted slice 'values'
-func (p *poolImpl[T, R]) generator(ctx context.Context, values []T) <-chan T {
-	ans := make(chan T) // output chan
-
-	go func() { // creates worker that asynchronous writes values from inputted slice 'values' to chan
-		defer close(ans) // asynchronous closes the channel
-		for _, v := range values {
-			select {
-			case <-ctx.Done():
-				return // stops the working if context is closed
-			case ans <- v: // write v from values to ans
-			}
+// The function expand searches child elements of node and passes each of them to an idle worker through work chan.
+// If all workers are busy, the child is expanded by the calling worker itself, so the tree is walked depth-first
+// and only children of nodes on the current paths of workers are kept in memory
+func (p *poolImpl[T, R]) expand(ctx context.Context, node T, work chan<- T, pending *sync.WaitGroup, searcher Searcher[T]) {
+	defer pending.Done()  // node is expanded
+	if ctx.Err() != nil { // if context is closed
+		return // stops the working
+	}
+	for _, child := range searcher(node) { // searches child elements
+		if ctx.Err() != nil { // if context is closed
+			return // the rest of children is dropped
 		}
-	}()
-
-	return ans
-}
-
-// The function listWorker creates worker that will return chan of found child elements
-func (p *poolImpl[T, R]) listWorker(ctx context.Context, inp <-chan T, searcher Searcher[T]) <-chan T {
-	nextNodes := make([]T, 0) // slice of found child elements
-	for {
+		pending.Add(1) // increments score of nodes to expand
 		select {
-		case <-ctx.Done(): // is context is closed
-			return nil // returns nil and stops the work
-		case v, ok := <-inp:
-			if !ok {
-				if len(nextNodes) > 0 { // if there are some found child elements
-					return p.generator(ctx, nextNodes) // returns generated slice that returns element from nextNode
-				}
-				return nil // returns nil
-			}
-			found := searcher(v)                    // searches child elements
-			nextNodes = append(nextNodes, found...) // added them to nextNodes
+		case work <- child: // an idle worker takes the child
+		default: // all workers are busy
+			p.expand(ctx, child, work, pending, searcher) // expands it in place
 		}
 	}
 }
 
-// The function fanIn unions the slice of channels to one chan by pattern Fan-in
-func (p *poolImpl[T, R]) fanIn(ctx context.Context, channels []<-chan T) <-chan T {
-	ans := make(chan T)    // output chan that returns values from inputted channels
-	wg := sync.WaitGroup{} // sync.WaitGroup for asynchronous closing the channel
-
-	wg.Add(1) // increments score in wg
-	go func() {
-		defer wg.Done()               // decrements score in wg
-		for _, ch := range channels { // every chan in channels reads one worker
-			wg.Add(1) // increments score in wg
-			go func() {
-				defer wg.Done() // decrements score in wg
-
-				select {
-				case <-ctx.Done():
-					return
-				case v, ok := <-ch:
-					if !ok {
-						return
-					}
-					select {
-					case <-ctx.Done():
-						return
-					case ans <- v: // writes value to ans
-					}
-				}
-			}()
-		}
-	}()
-
-	go func() {
-		defer close(ans) // asynchronous closes the channel
-		wg.Wait()
-	}()
-
-	return ans
-}
-
 func (p *poolImpl[T, R]) List(ctx context.Context, workers int, start T, searcher Searcher[T]) {
-	select {
-	case <-ctx.Done(): // if context is already closed
+	if ctx.Err() != nil { // if context is already closed
 		return // stop the working
-	default:
-		found := searcher(start)       // finds child elements of start
-		inp := p.generator(ctx, found) // creates chan of this elements
-
-		wg := sync.WaitGroup{} // sync.WaitGroup for wait ending of working with tree's layer
-		rw := sync.RWMutex{}   // RWMutex to thread safe writing to slice channels
-	loop:
-		for {
-			channels := make([]<-chan T, 0) // slice of channels, each of which will transmit the elements that 1 worker found
-			for i := 0; i < workers; i++ {  // generates workers which will find child of current found elements
-				wg.Add(1) // increments score in wg
-				go func() {
-					defer wg.Done() // decrements score in wg
-					ch := p.listWorker(ctx, inp, searcher)
-					if ch != nil { // if worker found some child elements
-						rw.Lock()                       // makes a lock to perform thread-safe writing
-						channels = append(channels, ch) // its chan added to channels
-						rw.Unlock()                     // it is unlocked so that other goroutines can record
-					}
-				}()
-			}
-			wg.Wait() // waits until all workers return their channels
-			select {
-			case <-ctx.Done(): // if context is closed
-				return // stops the working
-			default:
-				if len(channels) > 0 { // if there are some channels
-					inp = p.fanIn(ctx, channels) // unions channels to one chan by pattern Fan-in
-					continue                     // searches for child elements of the next layer
-				}
-				break loop // stops the working
+	}
+	work := make(chan T)        // unbuffered chan, so that the node is handed over only to the idle worker
+	pending := sync.WaitGroup{} // sync.WaitGroup for wait ending of expanding of all found nodes
+	ready := sync.WaitGroup{}   // sync.WaitGroup for wait starting of workers
+	wg := sync.WaitGroup{}      // sync.WaitGroup for wait ending of workers
+
+	for range max(workers, 1) { // cycle for making workers
+		wg.Add(1)    // increments score in wg
+		ready.Add(1) // increments score in ready
+		go func() {
+			defer wg.Done()          // decrements score in wg
+			ready.Done()             // the worker is going to wait for nodes
+			for node := range work { // while chan work isn't closed
+				p.expand(ctx, node, work, &pending, searcher)
 			}
-		}
+		}()
 	}
+
+	ready.Wait()   // so that children of start are handed over to other workers rather than expanded in place
+	pending.Add(1) // start is the first node to expand
+	work <- start  // some worker is idle, because nothing else has been found yet
+	pending.Wait() // waits until all found nodes are expanded or dropped
+	close(work)    // stops idle workers
+	wg.Wait()      // waits until workers exit, so that no goroutine leaks
 }
 
 func (p *poolImpl[T, R]) Transform(
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -114,6 +115,65 @@ func TestList(t *testing.T) {
 	require.LessOrEqual(t, runtime.NumGoroutine(), 3)
 }
 
+func TestListDeepTree(t *testing.T) {
+	ctx := context.Background()
+	wp := New[TestType, TestType]()
+
+	// every node has three children until depth 4, so a single worker finds several nodes of the next layer
+	counter := atomic.Int64{}
+	searcher := func(parent TestType) []TestType {
+		counter.Add(1)
+		if parent.Data >= 1000 {
+			return []TestType{}
+		}
+
+		return []TestType{{parent.Data*10 + 1}, {parent.Data*10 + 2}, {parent.Data*10 + 3}}
+	}
+
+	for _, workers := range []int{1, 2, 10} {
+		counter.Store(0)
+		wp.List(ctx, workers, TestType{Data: 1}, searcher)
+		require.EqualValues(t, 1+3+9+27, counter.Load())
+	}
+	require.LessOrEqual(t, runtime.NumGoroutine(), 3)
+}
+
+func TestListStreams(t *testing.T) {
+	ctx := context.Background()
+	wp := New[TestType, TestType]()
+
+	// every node has ten children until depth 5, the last layer has 100000 nodes
+	const fanout, depth, workers = 10, 5, 4
+	var (
+		mu                   sync.Mutex
+		outstanding, maximum int // count of found, but not expanded nodes
+		expanded             int
+	)
+	searcher := func(parent TestType) []TestType {
+		mu.Lock()
+		defer mu.Unlock()
+		outstanding--
+		expanded++
+		if parent.Data >= 100000 {
+			return []TestType{}
+		}
+
+		children := make([]TestType, fanout)
+		for i := range children {
+			children[i] = TestType{parent.Data*fanout + int64(i)}
+		}
+		outstanding += fanout
+		maximum = max(maximum, outstanding)
+		return children
+	}
+
+	outstanding = 1
+	wp.List(ctx, workers, TestType{Data: 1}, searcher)
+	require.Equal(t, 1+10+100+1000+10000+100000, expanded)
+	require.LessOrEqual(t, maximum, workers*(depth+1)*fanout) // layers aren't collected
+	require.LessOrEqual(t, runtime.NumGoroutine(), 3)
+}
+
 func TestListContextDone(t *testing.T) {
 	t.Run("end", func(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
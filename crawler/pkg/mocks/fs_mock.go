@@ -59,6 +59,21 @@ func (mr *MockFileSystemMockRecorder) Join(elem ...any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Join", reflect.TypeOf((*MockFileSystem)(nil).Join), elem...)
 }
 
+// Lstat mocks base method.
+func (m *MockFileSystem) Lstat(name string) (os.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lstat", name)
+	ret0, _ := ret[0].(os.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lstat indicates an expected call of Lstat.
+func (mr *MockFileSystemMockRecorder) Lstat(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lstat", reflect.TypeOf((*MockFileSystem)(nil).Lstat), name)
+}
+
 // Open mocks base method.
 func (m *MockFileSystem) Open(name string) (fs.File, error) {
 	m.ctrl.T.Helper()
@@ -89,6 +104,21 @@ func (mr *MockFileSystemMockRecorder) ReadDir(name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadDir", reflect.TypeOf((*MockFileSystem)(nil).ReadDir), name)
 }
 
+// Stat mocks base method.
+func (m *MockFileSystem) Stat(name string) (os.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stat", name)
+	ret0, _ := ret[0].(os.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stat indicates an expected call of Stat.
+func (mr *MockFileSystemMockRecorder) Stat(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockFileSystem)(nil).Stat), name)
+}
+
 // MockFile is a mock of File interface.
 type MockFile struct {
 	ctrl     *gomock.Controller
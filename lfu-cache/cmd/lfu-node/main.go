@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"lfucache/internal/lfu"
+	"lfucache/internal/server"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	capacity := flag.Int("capacity", 100_000, "count of keys in the cache")
+	shards := flag.Int("shards", 16, "count of shards of the cache")
+	snapshot := flag.String("snapshot", "", "file to restore the cache from on start and to save it to on shutdown")
+	maxValueSize := flag.Int64("max-value-size", server.DefaultMaxValueSize, "limit of size of value in bytes")
+	shutdownTimeout := flag.Duration("shutdown-timeout", server.DefaultShutdownTimeout, "time given to running requests on shutdown")
+	flag.Parse()
+
+	cache, err := lfu.TryNewSharded[string, []byte](*capacity, *shards)
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv := server.New(cache, server.Config{
+		SnapshotPath:    *snapshot,
+		MaxValueSize:    *maxValueSize,
+		ShutdownTimeout: *shutdownTimeout,
+	})
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("serving cache of %d keys on %s", *capacity, ln.Addr())
+	if err := srv.Serve(ctx, ln); err != nil {
+		log.Fatal(err)
+	}
+	log.Print("cache has been stopped")
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"lfucache/internal/lfu"
+	"lfucache/internal/trace"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Names of caches, which can be replayed, in the order of the default list
+var policies = []string{"lfu", "lru", "arc", "lfu-da", "tinylfu"}
+
+func main() {
+	format := flag.String("format", "zipf", "format of trace: csv, arc, lirs or synthetic zipf, scan, loop")
+	file := flag.String("file", "", "file with trace, standard input is read, if it isn't set")
+	column := flag.Int("column", 0, "column of key in csv trace, columns are numbered from zero")
+	header := flag.Bool("header", false, "skip the first line of csv trace")
+	length := flag.Int("length", 1_000_000, "length of synthetic trace")
+	keys := flag.Int("keys", 100_000, "count of different keys in synthetic zipf and loop traces")
+	skew := flag.Float64("s", 1.1, "skew of synthetic zipf trace, must be greater than one")
+	seed := flag.Uint64("seed", 42, "seed of synthetic zipf trace")
+	capacities := flag.String("capacities", "1000,10000", "comma separated capacities of caches")
+	names := flag.String("policies", strings.Join(policies, ","), "comma separated caches: "+strings.Join(policies, ", "))
+	flag.Parse()
+
+	if err := checkSynthetic(*length, *keys, *skew); err != nil {
+		log.Fatal(err)
+	}
+	caps, err := parseCapacities(*capacities)
+	if err != nil {
+		log.Fatal(err)
+	}
+	selected := strings.Split(*names, ",")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "cache\tcapacity\taccesses\thit ratio\tops/s\tallocs/op\t")
+	switch *format {
+	case "csv":
+		keys, err := readFile(*file, func(r io.Reader) ([]string, error) { return trace.ReadCSV(r, *column, *header) })
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = replayAll(w, keys, selected, caps)
+	case "arc":
+		err = readAndReplay(w, *file, trace.ReadARC, selected, caps)
+	case "lirs":
+		err = readAndReplay(w, *file, trace.ReadLIRS, selected, caps)
+	case "zipf":
+		err = generateAndReplay(w, func() ([]uint64, error) { return trace.Zipf(*length, *keys, *skew, *seed) }, selected, caps)
+	case "scan":
+		err = generateAndReplay(w, func() ([]uint64, error) { return trace.Scan(*length, 0) }, selected, caps)
+	case "loop":
+		err = generateAndReplay(w, func() ([]uint64, error) { return trace.Loop(*length, *keys) }, selected, caps)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	_ = w.Flush()
+}
+
+// Function checks flags of synthetic traces, so that mistakes are reported before any trace is replayed
+func checkSynthetic(length, keys int, skew float64) error {
+	if length < 0 {
+		return fmt.Errorf("invalid length %d, it must not be negative", length)
+	}
+	if keys <= 0 {
+		return fmt.Errorf("invalid count of keys %d, it must be positive", keys)
+	}
+	if !(skew > 1) { // NaN isn't greater than one either
+		return fmt.Errorf("invalid skew %v, it must be greater than one", skew)
+	}
+	return nil
+}
+
+// Function parses comma separated list of positive capacities
+func parseCapacities(s string) ([]int, error) {
+	var caps []int
+	for _, field := range strings.Split(s, ",") {
+		capacity, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || capacity <= 0 {
+			return nil, fmt.Errorf("invalid capacity %q", field)
+		}
+		caps = append(caps, capacity)
+	}
+	return caps, nil
+}
+
+// Function reads trace from the file or from standard input, if name is empty
+func readFile[K comparable](name string, read func(io.Reader) ([]K, error)) ([]K, error) {
+	if name == "" {
+		return read(os.Stdin)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+// Function reads trace of block numbers and replays it
+func readAndReplay(w io.Writer, name string, read func(io.Reader) ([]uint64, error), names []string, caps []int) error {
+	keys, err := readFile(name, read)
+	if err != nil {
+		return err
+	}
+	return replayAll(w, keys, names, caps)
+}
+
+// Function generates synthetic trace and replays it
+func generateAndReplay(w io.Writer, generate func() ([]uint64, error), names []string, caps []int) error {
+	keys, err := generate()
+	if err != nil {
+		return err
+	}
+	return replayAll(w, keys, names, caps)
+}
+
+// Function replays trace against every selected cache of every capacity and writes results as the row of the table
+func replayAll[K comparable](w io.Writer, keys []K, names []string, caps []int) error {
+	for _, name := range names {
+		for _, capacity := range caps {
+			cache, err := newCache[K](strings.TrimSpace(name), capacity)
+			if err != nil {
+				return err
+			}
+			res := trace.Replay(cache, keys)
+			fmt.Fprintf(w, "%s\t%d\t%d\t%.2f%%\t%.0f\t%.2f\t\n",
+				name, capacity, res.Accesses, res.HitRatio()*100, res.Throughput(), res.AllocsPerAccess())
+		}
+	}
+	return nil
+}
+
+// Function creates cache by its name, values aren't needed for replay, so they are empty
+func newCache[K comparable](name string, capacity int) (lfu.Cache[K, struct{}], error) {
+	if name == "tinylfu" {
+		return lfu.NewTinyLFU[K, struct{}](capacity), nil
+	}
+	for _, policy := range []lfu.Policy{lfu.PolicyLFU, lfu.PolicyLRU, lfu.PolicyARC, lfu.PolicyLFUDA} {
+		if policy.String() == name {
+			return lfu.TryNewCache(capacity, lfu.WithPolicy[K, struct{}](policy))
+		}
+	}
+	return nil, fmt.Errorf("unknown cache %q", name)
+}
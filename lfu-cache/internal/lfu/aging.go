@@ -0,0 +1,38 @@
+package lfu
+
+// Age halves frequencies of all keys, frequency can't become less than one.
+// Keys, whose frequencies become equal, are merged into one class: keys with bigger old frequency
+// are considered more recently used, order of keys with the same old frequency is kept.
+//
+// O(capacity)
+func (l *cacheImpl[K, V]) Age() {
+	classes := l.frequencyClasses
+	for classNode := classes.Front(); classNode != nil; classNode = classNode.Next(classes) { // iterates from the highest frequency
+		class := classNode.Data
+		class.frequency = max(class.frequency/2, 1)
+
+		prevNode := classNode.Prev(classes) // class with bigger old frequency, it has been already halved
+		if prevNode == nil || prevNode.Data.frequency != class.frequency {
+			continue
+		}
+		prevLst := prevNode.Data.lst
+		for prevLst.Size() > 0 { // moves keys of prevNode to the front of class from the least recently used one
+			keyNode := prevLst.Back()
+			class.lst.MoveToFront(keyNode, prevLst)
+			keyNode.Data.nodeFreqClass = classNode
+		}
+		l.releaseClass(prevNode)
+	}
+}
+
+// Function counts calls of Get and Put and ages frequencies, when agingPeriod calls have been made
+func (l *cacheImpl[K, V]) tick() {
+	if l.agingPeriod == 0 { // if aging is disabled
+		return
+	}
+	l.opsSinceAging++
+	if l.opsSinceAging == l.agingPeriod {
+		l.opsSinceAging = 0
+		l.Age()
+	}
+}
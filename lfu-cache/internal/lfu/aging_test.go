@@ -0,0 +1,152 @@
+package lfu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func frequencies[K comparable, V any](t *testing.T, cache *cacheImpl[K, V], keys []K) []int {
+	t.Helper()
+
+	result := make([]int, 0, len(keys))
+	for _, k := range keys {
+		freq, err := cache.GetKeyFrequency(k)
+		require.NoError(t, err)
+		result = append(result, freq)
+	}
+	return result
+}
+
+func TestAgeHalvesFrequencies(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, int](5)
+
+	for i := 1; i <= 5; i++ {
+		for range i * 2 {
+			cache.Put(i, i)
+		}
+	}
+
+	cache.Age()
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{5, 4, 3, 2, 1}, keys)
+	require.Equal(t, []int{5, 4, 3, 2, 1}, frequencies(t, cache, keys))
+}
+
+func TestAgeMergesClasses(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, int](6)
+
+	putTimes := map[int]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 5}
+	for _, k := range []int{1, 2, 3, 4, 5, 6} {
+		for range putTimes[k] {
+			cache.Put(k, k)
+		}
+	}
+	require.Equal(t, 5, cache.frequencyClasses.Size())
+
+	cache.Age()
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{6, 5, 4, 3, 2, 1}, keys)
+	require.Equal(t, []int{2, 2, 2, 1, 1, 1}, frequencies(t, cache, []int{6, 5, 4, 3, 2, 1}))
+	require.Equal(t, 2, cache.frequencyClasses.Size())
+}
+
+func TestEvictionOrderAfterAging(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, int](3)
+
+	for range 10 {
+		cache.Put(1, 1)
+	}
+	cache.Put(2, 2)
+	cache.Put(3, 3)
+	cache.Put(3, 3)
+
+	cache.Age()
+	cache.Age()
+	cache.Age()
+	cache.Age() // 1 -> 1, 3 -> 1, 2 -> 1; order in class: 1, 3, 2
+
+	cache.Put(4, 4) // evicts 2
+	_, err := cache.Get(2)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	cache.Put(5, 5) // evicts 3 as the least recently used key with frequency 1
+	_, err = cache.Get(3)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{5, 4, 1}, keys)
+}
+
+func TestAgingPeriod(t *testing.T) {
+	t.Parallel()
+
+	cache := NewWithOptions(2, WithAgingPeriod[int, int](4))
+
+	cache.Put(1, 1)
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(1)
+
+	freq, err := cache.GetKeyFrequency(1)
+	require.NoError(t, err)
+	require.Equal(t, 3, freq)
+
+	_, _ = cache.Get(1) // 4th call: frequency becomes 4 and then is halved
+
+	freq, err = cache.GetKeyFrequency(1)
+	require.NoError(t, err)
+	require.Equal(t, 2, freq)
+}
+
+func TestMaxFrequency(t *testing.T) {
+	t.Parallel()
+
+	cache := NewWithOptions(3, WithMaxFrequency[int, int](2))
+
+	cache.Put(1, 1)
+	cache.Put(2, 2)
+	for range 10 {
+		_, _ = cache.Get(1)
+	}
+	_, _ = cache.Get(2)
+
+	require.Equal(t, []int{2, 2}, frequencies(t, cache, []int{1, 2}))
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{2, 1}, keys)
+
+	cache.Put(3, 3)
+	_, _ = cache.Get(3)
+	cache.Put(4, 4) // all keys have frequency 2, so the least recently used one is evicted
+
+	_, err := cache.Get(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestShardedAge(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded(4, 2, WithHasher[int, int](func(key int) uint64 { return uint64(key) }))
+
+	for i := range 4 {
+		for range 4 {
+			cache.Put(i, i)
+		}
+	}
+
+	cache.Age()
+
+	for i := range 4 {
+		freq, err := cache.GetKeyFrequency(i)
+		require.NoError(t, err)
+		require.Equal(t, 2, freq)
+	}
+}
@@ -0,0 +1,219 @@
+package lfu
+
+import (
+	"iter"
+	"lfucache/pkg/linkedlist"
+)
+
+// List of arcCache, which contains the key
+type arcList int
+
+const (
+	arcRecent        arcList = iota // T1: keys used once since they have been put
+	arcFrequent                     // T2: keys used at least twice
+	arcRecentGhost                  // B1: keys recently evicted from T1, values aren't stored
+	arcFrequentGhost                // B2: keys recently evicted from T2, values aren't stored
+)
+
+// arcCache represents Adaptive Replacement Cache implementation.
+// Resident keys are split into T1 (keys seen once recently) and T2 (keys seen at least twice).
+// Evicted keys are remembered without values in ghost lists B1 and B2. A Put of the key from B1 means that
+// T1 is too small, so target size of T1 grows, a Put of the key from B2 makes it shrink.
+// So the cache adapts itself between recency and frequency of the workload.
+type arcCache[K comparable, V any] struct {
+	policyBase[K, V]
+	lists  [4]linkedlist.LinkedList[arcEntry[K, V]] // T1, T2, B1 and B2 indexed by arcList, the most recently used key is the first
+	keys   map[K]*linkedlist.Node[arcEntry[K, V]]   // Map from key to node in one of lists
+	target int                                      // target size of T1, it is between zero and capacity
+}
+
+// Structure that is contained by the node of arcCache
+type arcEntry[K comparable, V any] struct {
+	key       K
+	val       V
+	frequency int     // count of Get and Put of the key since it has become resident
+	list      arcList // list containing the node
+}
+
+// Factory of arcCache
+func newARC[K comparable, V any](capacity int, onEvict OnEvictFunc[K, V]) *arcCache[K, V] {
+	c := &arcCache[K, V]{
+		policyBase: policyBase[K, V]{capacity: capacity, onEvict: onEvict},
+		keys:       make(map[K]*linkedlist.Node[arcEntry[K, V]], 2*capacity),
+	}
+	for i := range c.lists {
+		c.lists[i] = linkedlist.NewLinkedList[arcEntry[K, V]]()
+	}
+	return c
+}
+
+// Function moves node to the front of the given list
+func (c *arcCache[K, V]) move(node *linkedlist.Node[arcEntry[K, V]], to arcList) {
+	c.lists[to].MoveToFront(node, c.lists[node.Data.list])
+	node.Data.list = to
+}
+
+// Function returns node of resident key or nil, if key isn't resident
+func (c *arcCache[K, V]) resident(key K) *linkedlist.Node[arcEntry[K, V]] {
+	if node, ok := c.keys[key]; ok && node.Data.list <= arcFrequent {
+		return node
+	}
+	return nil
+}
+
+// Function evicts one resident key to the ghost list, so that there is place for a new one.
+// The key is taken from T1, if T1 exceeds its target size, otherwise from T2
+func (c *arcCache[K, V]) replace(fromFrequentGhost bool) {
+	t1 := c.lists[arcRecent].Size()
+	from, to := arcFrequent, arcFrequentGhost
+	if t1 > 0 && (t1 > c.target || (fromFrequentGhost && t1 == c.target) || c.lists[arcFrequent].Size() == 0) {
+		from, to = arcRecent, arcRecentGhost
+	}
+	node := c.lists[from].Back()
+	c.move(node, to)
+	val := node.Data.val
+	var zeroVal V
+	node.Data.val = zeroVal // ghost doesn't keep value, so it can be collected
+	node.Data.frequency = 0
+	c.notifyEvict(node.Data.key, val, EvictReasonCapacity)
+}
+
+// Function forgets the least recently evicted key of the ghost list
+func (c *arcCache[K, V]) dropGhost(list arcList) {
+	node := c.lists[list].PopBack()
+	delete(c.keys, node.Data.key)
+}
+
+func (c *arcCache[K, V]) Get(key K) (V, error) {
+	node := c.resident(key)
+	c.countGet(node != nil)
+	if node == nil {
+		var zeroVal V
+		return zeroVal, ErrKeyNotFound
+	}
+	node.Data.frequency++
+	c.move(node, arcFrequent) // the second use makes key frequent
+	return node.Data.val, nil
+}
+
+// Put works like Cache.Put, but the key to evict is chosen by ARC.
+func (c *arcCache[K, V]) Put(key K, value V) {
+	c.stats.puts.Add(1)
+	node, ok := c.keys[key]
+	switch {
+	case ok && node.Data.list <= arcFrequent: // if key is resident, updates it like Get
+		oldValue := node.Data.val
+		node.Data.val = value
+		node.Data.frequency++
+		c.move(node, arcFrequent)
+		c.notifyEvict(key, oldValue, EvictReasonReplaced)
+		return
+	case ok: // if key is in ghost list, it was evicted too early, so target of its list grows
+		b1, b2 := c.lists[arcRecentGhost].Size(), c.lists[arcFrequentGhost].Size()
+		fromFrequentGhost := node.Data.list == arcFrequentGhost
+		if fromFrequentGhost {
+			c.target = max(c.target-max(b1/b2, 1), 0)
+		} else {
+			c.target = min(c.target+max(b2/b1, 1), c.capacity)
+		}
+		if c.Size() == c.capacity {
+			c.replace(fromFrequentGhost)
+		}
+		node.Data.val = value
+		node.Data.frequency = 1
+		c.move(node, arcFrequent)
+		return
+	case c.capacity == 0: // if there is no place at all
+		c.notifyEvict(key, value, EvictReasonRejected)
+		return
+	}
+
+	t1, b1 := c.lists[arcRecent].Size(), c.lists[arcRecentGhost].Size()
+	if t1+b1 == c.capacity { // if T1 and B1 together are filled, forgets the oldest key of them
+		if t1 < c.capacity {
+			c.dropGhost(arcRecentGhost)
+			if c.Size() == c.capacity {
+				c.replace(false)
+			}
+		} else { // T1 takes all capacity, so its oldest key is evicted without remembering
+			victim := c.lists[arcRecent].PopBack().Data
+			delete(c.keys, victim.key)
+			c.notifyEvict(victim.key, victim.val, EvictReasonCapacity)
+		}
+	} else if total := t1 + b1 + c.lists[arcFrequent].Size() + c.lists[arcFrequentGhost].Size(); total >= c.capacity {
+		if total == 2*c.capacity { // if history is filled, forgets the oldest key of B2
+			c.dropGhost(arcFrequentGhost)
+		}
+		if c.Size() == c.capacity {
+			c.replace(false)
+		}
+	}
+	c.keys[key] = c.lists[arcRecent].PushFront(arcEntry[K, V]{key, value, 1, arcRecent})
+}
+
+// All returns the iterator over resident keys: keys of T2 are listed before keys of T1,
+// the most recently used key of every list is listed first.
+//
+// O(capacity)
+func (c *arcCache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, list := range []arcList{arcFrequent, arcRecent} {
+			for entry := range c.lists[list].All() {
+				if !yield(entry.key, entry.val) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *arcCache[K, V]) Size() int {
+	return c.lists[arcRecent].Size() + c.lists[arcFrequent].Size()
+}
+
+func (c *arcCache[K, V]) GetKeyFrequency(key K) (int, error) {
+	if node := c.resident(key); node != nil {
+		return node.Data.frequency, nil
+	}
+	return 0, ErrKeyNotFound
+}
+
+// Delete works like Cache.Delete, ghost keys aren't resident, so they can't be deleted.
+func (c *arcCache[K, V]) Delete(key K) bool {
+	node := c.resident(key)
+	if node == nil {
+		return false
+	}
+	c.lists[node.Data.list].Remove(node)
+	delete(c.keys, key)
+	c.notifyEvict(key, node.Data.val, EvictReasonDeleted)
+	return true
+}
+
+// Clear works like Cache.Clear, history of evicted keys and target size of T1 are forgotten too.
+func (c *arcCache[K, V]) Clear() {
+	old := c.lists
+	for i := range c.lists {
+		c.lists[i] = linkedlist.NewLinkedList[arcEntry[K, V]]()
+	}
+	c.keys = make(map[K]*linkedlist.Node[arcEntry[K, V]], 2*c.capacity)
+	c.target = 0
+	for _, list := range []arcList{arcFrequent, arcRecent} {
+		for entry := range old[list].All() {
+			c.notifyEvict(entry.key, entry.val, EvictReasonDeleted)
+		}
+	}
+}
+
+func (c *arcCache[K, V]) Stats() Stats {
+	stats := c.stats.snapshot()
+	stats.Size = c.Size()
+	stats.FrequencyHistogram = make(map[int]int)
+	for _, list := range []arcList{arcFrequent, arcRecent} {
+		for entry := range c.lists[list].All() {
+			stats.FrequencyHistogram[entry.frequency]++
+		}
+	}
+	stats.FrequencyClasses = len(stats.FrequencyHistogram)
+	return stats
+}
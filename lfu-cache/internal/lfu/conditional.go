@@ -0,0 +1,169 @@
+package lfu
+
+// CompareAndSwap updates the value of the key like Put, if the key is present and its value equals oldValue by ==,
+// and reports whether the value was swapped. Values, which aren't comparable, like []byte, are swapped
+// by Cache.CompareAndSwapFunc with the suitable function, e.g. bytes.Equal.
+//
+// O(1)
+func CompareAndSwap[K, V comparable](cache Cache[K, V], key K, oldValue, newValue V) bool {
+	return cache.CompareAndSwapFunc(key, oldValue, newValue, func(a, b V) bool { return a == b })
+}
+
+// Function implements PutIfAbsent by Contains and Put, it is used by caches, which aren't thread-safe
+func putIfAbsent[K comparable, V any](c Cache[K, V], key K, value V) bool {
+	if c.Contains(key) {
+		return false
+	}
+	c.Put(key, value)
+	return true
+}
+
+// Function implements CompareAndSwapFunc by Peek and Put, it is used by caches, which aren't thread-safe
+func compareAndSwap[K comparable, V any](c Cache[K, V], key K, oldValue, newValue V, eq func(a, b V) bool) bool {
+	if cur, err := c.Peek(key); err != nil || !eq(cur, oldValue) {
+		return false
+	}
+	c.Put(key, newValue)
+	return true
+}
+
+// Peek works like Cache.Peek, expired key isn't found, but isn't removed either.
+func (l *cacheImpl[K, V]) Peek(key K) (V, error) {
+	if keyNode, ok := l.keyToElements[key]; ok && !l.expired(keyNode) {
+		return keyNode.Data.val, nil
+	}
+	var zeroVal V
+	return zeroVal, ErrKeyNotFound
+}
+
+func (l *cacheImpl[K, V]) Contains(key K) bool {
+	_, err := l.Peek(key)
+	return err == nil
+}
+
+// PutIfAbsent works like Cache.PutIfAbsent, expired key is considered absent, so it is replaced.
+func (l *cacheImpl[K, V]) PutIfAbsent(key K, value V) bool {
+	return putIfAbsent[K, V](l, key, value)
+}
+
+func (l *cacheImpl[K, V]) CompareAndSwapFunc(key K, oldValue, newValue V, eq func(a, b V) bool) bool {
+	return compareAndSwap[K, V](l, key, oldValue, newValue, eq)
+}
+
+func (c *shardedCache[K, V]) Peek(key K) (V, error) {
+	s := c.shardOf(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Peek(key)
+}
+
+func (c *shardedCache[K, V]) Contains(key K) bool {
+	s := c.shardOf(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Contains(key)
+}
+
+// PutIfAbsent works like Cache.PutIfAbsent, check and insertion are done atomically under the lock of shard.
+func (c *shardedCache[K, V]) PutIfAbsent(key K, value V) bool {
+	s := c.shardOf(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cache.PutIfAbsent(key, value) {
+		return false
+	}
+	s.forgetKey(key)
+	return true
+}
+
+// CompareAndSwapFunc works like Cache.CompareAndSwapFunc, comparison and update are done atomically under the lock
+// of shard, eq is called under the lock too, so it mustn't use the cache.
+func (c *shardedCache[K, V]) CompareAndSwapFunc(key K, oldValue, newValue V, eq func(a, b V) bool) bool {
+	s := c.shardOf(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.CompareAndSwapFunc(key, oldValue, newValue, eq)
+}
+
+func (c *tinyLFUCache[K, V]) Peek(key K) (V, error) {
+	if node, ok := c.windowKeys[key]; ok {
+		return node.Data.val, nil
+	}
+	return c.main.Peek(key)
+}
+
+func (c *tinyLFUCache[K, V]) Contains(key K) bool {
+	_, err := c.Peek(key)
+	return err == nil
+}
+
+func (c *tinyLFUCache[K, V]) PutIfAbsent(key K, value V) bool {
+	return putIfAbsent[K, V](c, key, value)
+}
+
+func (c *tinyLFUCache[K, V]) CompareAndSwapFunc(key K, oldValue, newValue V, eq func(a, b V) bool) bool {
+	return compareAndSwap[K, V](c, key, oldValue, newValue, eq)
+}
+
+func (c *lruCache[K, V]) Peek(key K) (V, error) {
+	if node, ok := c.keys[key]; ok {
+		return node.Data.val, nil
+	}
+	var zeroVal V
+	return zeroVal, ErrKeyNotFound
+}
+
+func (c *lruCache[K, V]) Contains(key K) bool {
+	_, ok := c.keys[key]
+	return ok
+}
+
+func (c *lruCache[K, V]) PutIfAbsent(key K, value V) bool {
+	return putIfAbsent[K, V](c, key, value)
+}
+
+func (c *lruCache[K, V]) CompareAndSwapFunc(key K, oldValue, newValue V, eq func(a, b V) bool) bool {
+	return compareAndSwap[K, V](c, key, oldValue, newValue, eq)
+}
+
+// Peek works like Cache.Peek, ghost keys aren't resident, so they aren't found.
+func (c *arcCache[K, V]) Peek(key K) (V, error) {
+	if node := c.resident(key); node != nil {
+		return node.Data.val, nil
+	}
+	var zeroVal V
+	return zeroVal, ErrKeyNotFound
+}
+
+func (c *arcCache[K, V]) Contains(key K) bool {
+	return c.resident(key) != nil
+}
+
+func (c *arcCache[K, V]) PutIfAbsent(key K, value V) bool {
+	return putIfAbsent[K, V](c, key, value)
+}
+
+func (c *arcCache[K, V]) CompareAndSwapFunc(key K, oldValue, newValue V, eq func(a, b V) bool) bool {
+	return compareAndSwap[K, V](c, key, oldValue, newValue, eq)
+}
+
+func (c *lfudaCache[K, V]) Peek(key K) (V, error) {
+	if node, ok := c.keys[key]; ok {
+		return node.Data.val, nil
+	}
+	var zeroVal V
+	return zeroVal, ErrKeyNotFound
+}
+
+func (c *lfudaCache[K, V]) Contains(key K) bool {
+	_, ok := c.keys[key]
+	return ok
+}
+
+func (c *lfudaCache[K, V]) PutIfAbsent(key K, value V) bool {
+	return putIfAbsent[K, V](c, key, value)
+}
+
+func (c *lfudaCache[K, V]) CompareAndSwapFunc(key K, oldValue, newValue V, eq func(a, b V) bool) bool {
+	return compareAndSwap[K, V](c, key, oldValue, newValue, eq)
+}
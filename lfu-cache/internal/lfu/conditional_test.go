@@ -0,0 +1,120 @@
+package lfu
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeekExpired(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	recorder := new(evictRecorder)
+	cache := NewWithOptions(2, withClock[int, int](clock.Now), WithOnEvict(recorder.onEvict))
+
+	cache.PutWithTTL(1, 10, time.Second)
+	clock.Advance(time.Second)
+
+	_, err := cache.Peek(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.False(t, cache.Contains(1))
+	require.Equal(t, 1, cache.Size()) // Peek doesn't remove expired key
+	require.Empty(t, recorder.events)
+
+	require.True(t, cache.PutIfAbsent(1, 11))
+	value, err := cache.Peek(1)
+	require.NoError(t, err)
+	require.Equal(t, 11, value)
+}
+
+func TestCompareAndSwapFrequencyAndCallback(t *testing.T) {
+	t.Parallel()
+
+	recorder := new(evictRecorder)
+	cache := NewWithOptions(2, WithOnEvict(recorder.onEvict))
+
+	cache.Put(1, 10)
+	require.True(t, CompareAndSwap(cache, 1, 10, 11))
+
+	freq, err := cache.GetKeyFrequency(1)
+	require.NoError(t, err)
+	require.Equal(t, 2, freq)
+	require.Equal(t, []evictEvent{{1, 10, EvictReasonReplaced}}, recorder.events)
+}
+
+type blob []byte
+
+func TestCompareAndSwapFuncNotComparable(t *testing.T) {
+	t.Parallel()
+
+	equalBlobs := func(a, b blob) bool { return bytes.Equal(a, b) }
+	for name, cache := range map[string]Cache[int, blob]{
+		"lfu":     New[int, blob](2),
+		"sharded": NewSharded[int, blob](4, 2),
+		"lru":     NewCache(2, WithPolicy[int, blob](PolicyLRU)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cache.Put(1, blob("a"))
+			require.False(t, cache.CompareAndSwapFunc(1, blob("b"), blob("c"), equalBlobs))
+			require.True(t, cache.CompareAndSwapFunc(1, blob("a"), blob("c"), equalBlobs)) // contents are compared
+			value, err := cache.Peek(1)
+			require.NoError(t, err)
+			require.Equal(t, blob("c"), value)
+		})
+	}
+}
+
+func TestShardedPutIfAbsentConcurrent(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded(64, 4, identityHasher())
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		put int
+	)
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if cache.PutIfAbsent(42, i) {
+				mu.Lock()
+				put++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 1, put)
+
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				cur, err := cache.Peek(7)
+				if err != nil {
+					if cache.PutIfAbsent(7, 1) {
+						return
+					}
+					continue
+				}
+				if CompareAndSwap(cache, 7, cur, cur+1) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	value, err := cache.Peek(7)
+	require.NoError(t, err)
+	require.Equal(t, 16, value)
+}
@@ -0,0 +1,60 @@
+package lfu
+
+import (
+	"lfucache/pkg/linkedlist"
+)
+
+// CostFunc computes cost of the key and value, for example, count of bytes they hold
+type CostFunc[K comparable, V any] func(key K, value V) int64
+
+// Cost returns total cost of keys in the cache, it is always zero, if the cost isn't limited.
+//
+// O(1)
+func (l *cacheImpl[K, V]) Cost() int64 {
+	return l.cost
+}
+
+// MaxCost returns the limit of total cost of keys in the cache or zero, if the cost isn't limited.
+//
+// O(1)
+func (l *cacheImpl[K, V]) MaxCost() int64 {
+	return l.maxCost
+}
+
+// Function evicts the least frequently used keys, until value of the key with the given cost fits in the cache.
+// Old value of the key itself is never evicted, its cost is replaced by the new one.
+// If the value costs more than maxCost, function removes old value of the key and returns false
+func (l *cacheImpl[K, V]) reserveCost(key K, value V, cost int64) bool {
+	keyNode := l.lookup(key)
+	if cost > l.maxCost { // if value can't fit in the cache even if it is empty
+		if keyNode != nil { // old value can't stay in the cache instead of the new one
+			l.removeNode(keyNode, EvictReasonReplaced)
+		}
+		l.notifyEvict(key, value, EvictReasonRejected)
+		return false
+	}
+
+	freed := int64(0)
+	if keyNode != nil { // old value of the key will be replaced, so its cost is freed
+		freed = keyNode.Data.cost
+	}
+	for l.cost-freed+cost > l.maxCost {
+		l.removeNode(l.victimNode(keyNode), EvictReasonCapacity)
+	}
+	return true
+}
+
+// Function returns node of the least frequently and the least recently used key except keep.
+// Keep may be nil. Function is O(1), because keep is skipped at most once
+func (l *cacheImpl[K, V]) victimNode(keep *linkedlist.Node[valOfKey[K, V]]) *linkedlist.Node[valOfKey[K, V]] {
+	classes := l.frequencyClasses
+	for classNode := classes.Back(); classNode != nil; classNode = classNode.Prev(classes) { // iterates from the least frequency
+		lst := classNode.Data.lst
+		for keyNode := lst.Back(); keyNode != nil; keyNode = keyNode.Prev(lst) {
+			if keyNode != keep {
+				return keyNode
+			}
+		}
+	}
+	return nil
+}
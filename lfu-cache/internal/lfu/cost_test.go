@@ -0,0 +1,136 @@
+package lfu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func valueCost(_ int, value int) int64 {
+	return int64(value)
+}
+
+func TestMaxCostEvictsLeastFrequent(t *testing.T) {
+	t.Parallel()
+
+	recorder := new(evictRecorder)
+	cache := NewWithOptions(10, WithMaxCost(10, valueCost), WithOnEvict(recorder.onEvict))
+	require.Equal(t, int64(10), cache.MaxCost())
+
+	cache.Put(1, 3)
+	cache.Put(2, 3)
+	cache.Put(3, 3)
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(3)
+	require.Equal(t, int64(9), cache.Cost())
+
+	cache.Put(4, 5) // evicts 2 and then 1 as it is older than 3
+
+	require.Equal(t, []evictEvent{
+		{2, 3, EvictReasonCapacity},
+		{1, 3, EvictReasonCapacity},
+	}, recorder.events)
+	require.Equal(t, int64(8), cache.Cost())
+	require.Equal(t, 2, cache.Size())
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{3, 4}, keys)
+}
+
+func TestMaxCostRejectsTooExpensiveValue(t *testing.T) {
+	t.Parallel()
+
+	recorder := new(evictRecorder)
+	cache := NewWithOptions(10, WithMaxCost(10, valueCost), WithOnEvict(recorder.onEvict))
+
+	cache.Put(1, 2)
+	cache.Put(2, 2)
+	cache.Put(3, 11)
+	cache.Put(1, 11)
+
+	require.Equal(t, []evictEvent{
+		{3, 11, EvictReasonRejected},
+		{1, 2, EvictReasonReplaced},
+		{1, 11, EvictReasonRejected},
+	}, recorder.events)
+	require.Equal(t, int64(2), cache.Cost())
+
+	_, err := cache.Get(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	v, err := cache.Get(2)
+	require.NoError(t, err)
+	require.Equal(t, 2, v)
+}
+
+func TestMaxCostUpdateDoesNotEvictItself(t *testing.T) {
+	t.Parallel()
+
+	cache := NewWithOptions(10, WithMaxCost(10, valueCost))
+
+	cache.Put(1, 2)
+	for range 5 {
+		_, _ = cache.Get(1)
+	}
+	cache.Put(2, 2)
+	cache.Put(3, 2)
+
+	cache.Put(3, 7) // 3 is the least frequently used key after the update, but only 2 is evicted
+
+	_, err := cache.Get(2)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	v, err := cache.Get(3)
+	require.NoError(t, err)
+	require.Equal(t, 7, v)
+	require.Equal(t, int64(9), cache.Cost())
+}
+
+func TestMaxCostWithCountLimit(t *testing.T) {
+	t.Parallel()
+
+	cache := NewWithOptions(2, WithMaxCost[int, int](100, nil))
+
+	cache.Put(1, 1)
+	cache.Put(2, 2)
+	cache.Put(3, 3)
+
+	require.Equal(t, 2, cache.Size())
+	require.Equal(t, int64(2), cache.Cost())
+
+	cache.Delete(3)
+	require.Equal(t, int64(1), cache.Cost())
+
+	cache.Clear()
+	require.Equal(t, int64(0), cache.Cost())
+}
+
+func TestNoCostLimit(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, int](2)
+
+	cache.Put(1, 100)
+	require.Equal(t, int64(0), cache.Cost())
+	require.Equal(t, int64(0), cache.MaxCost())
+}
+
+func TestShardedMaxCost(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded(8, 2,
+		WithMaxCost(9, valueCost),
+		WithHasher[int, int](func(key int) uint64 { return uint64(key) }),
+	)
+	require.Equal(t, int64(9), cache.MaxCost())
+	require.Equal(t, int64(5), cache.shards[0].cache.MaxCost())
+	require.Equal(t, int64(4), cache.shards[1].cache.MaxCost())
+
+	cache.Put(0, 3)
+	cache.Put(2, 3) // evicts 0, limit of even shard is 5
+	cache.Put(1, 4)
+
+	_, err := cache.Get(0)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.Equal(t, int64(7), cache.Cost())
+
+	require.Panics(t, func() { NewSharded(8, 4, WithMaxCost[int, int](3, nil)) })
+}
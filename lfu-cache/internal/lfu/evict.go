@@ -0,0 +1,39 @@
+package lfu
+
+// EvictReason describes why the key has left the cache
+type EvictReason int
+
+const (
+	// EvictReasonCapacity means that the key was the least frequently used one, when the new key was put in the full cache
+	EvictReasonCapacity EvictReason = iota
+	// EvictReasonDeleted means that the key was removed by Delete or Clear
+	EvictReasonDeleted
+	// EvictReasonExpired means that time to live of the key has passed
+	EvictReasonExpired
+	// EvictReasonReplaced means that the value of the key was overwritten by Put, the key itself stays in the cache
+	EvictReasonReplaced
+	// EvictReasonRejected means that the value given to Put can't fit in the cache at all: it costs more than the whole cost limit
+	// or the capacity is zero, so it wasn't put
+	EvictReasonRejected
+)
+
+// OnEvictFunc is called with the key and its old value every time the value leaves the cache.
+// It is called synchronously, so it must not call methods of the cache.
+type OnEvictFunc[K comparable, V any] func(key K, value V, reason EvictReason)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictReasonCapacity:
+		return "capacity"
+	case EvictReasonDeleted:
+		return "deleted"
+	case EvictReasonExpired:
+		return "expired"
+	case EvictReasonReplaced:
+		return "replaced"
+	case EvictReasonRejected:
+		return "rejected"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,170 @@
+package lfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type evictEvent struct {
+	key    int
+	value  int
+	reason EvictReason
+}
+
+type evictRecorder struct {
+	events []evictEvent
+}
+
+func (r *evictRecorder) onEvict(key int, value int, reason EvictReason) {
+	r.events = append(r.events, evictEvent{key, value, reason})
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, int](3)
+
+	cache.Put(1, 10)
+	cache.Put(2, 20)
+	cache.Put(3, 30)
+	_, _ = cache.Get(2)
+
+	require.True(t, cache.Delete(2))
+	require.False(t, cache.Delete(2))
+	require.False(t, cache.Delete(42))
+	require.Equal(t, 2, cache.Size())
+
+	_, err := cache.Get(2)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	cache.Put(4, 40)
+	cache.Put(5, 50) // evicts 1
+
+	keys, values := collect(cache.All())
+	require.Equal(t, []int{5, 4, 3}, keys)
+	require.Equal(t, []int{50, 40, 30}, values)
+}
+
+func TestDeleteLastKeyOfHighFrequency(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, int](2)
+
+	cache.Put(1, 10)
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(1)
+
+	require.True(t, cache.Delete(1))
+	require.Equal(t, 0, cache.Size())
+
+	cache.Put(2, 20)
+	frequency, err := cache.GetKeyFrequency(2)
+	require.NoError(t, err)
+	require.Equal(t, 1, frequency)
+}
+
+func TestClear(t *testing.T) {
+	t.Parallel()
+
+	recorder := new(evictRecorder)
+	cache := NewWithOptions(3, WithOnEvict(recorder.onEvict))
+
+	cache.Put(1, 10)
+	cache.Put(2, 20)
+	_, _ = cache.Get(1)
+
+	cache.Clear()
+
+	require.Equal(t, 0, cache.Size())
+	keys, _ := collect(cache.All())
+	require.Empty(t, keys)
+	require.Equal(t, []evictEvent{
+		{1, 10, EvictReasonDeleted},
+		{2, 20, EvictReasonDeleted},
+	}, recorder.events)
+
+	cache.Put(3, 30)
+	v, err := cache.Get(3)
+	require.NoError(t, err)
+	require.Equal(t, 30, v)
+}
+
+func TestOnEvictCapacity(t *testing.T) {
+	t.Parallel()
+
+	recorder := new(evictRecorder)
+	cache := NewWithOptions(2, WithOnEvict(recorder.onEvict))
+
+	cache.Put(1, 10)
+	cache.Put(2, 20)
+	_, _ = cache.Get(1)
+	cache.Put(3, 30)
+	cache.Put(4, 40)
+
+	require.Equal(t, []evictEvent{
+		{2, 20, EvictReasonCapacity},
+		{3, 30, EvictReasonCapacity},
+	}, recorder.events)
+}
+
+func TestOnEvictReplacedDeletedExpired(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	recorder := new(evictRecorder)
+	cache := NewWithOptions(5, WithOnEvict(recorder.onEvict), withClock[int, int](clock.Now))
+
+	cache.Put(1, 10)
+	cache.Put(1, 11)
+	cache.Put(2, 20)
+	cache.Delete(2)
+	cache.PutWithTTL(3, 30, time.Second)
+	cache.PutWithTTL(4, 40, time.Second)
+
+	clock.Advance(time.Second)
+
+	_, _ = cache.Get(3)
+	cache.DeleteExpired()
+
+	require.Equal(t, []evictEvent{
+		{1, 10, EvictReasonReplaced},
+		{2, 20, EvictReasonDeleted},
+		{3, 30, EvictReasonExpired},
+		{4, 40, EvictReasonExpired},
+	}, recorder.events)
+}
+
+func TestShardedDeleteAndClear(t *testing.T) {
+	t.Parallel()
+
+	evicted := 0
+	cache := NewSharded(8, 4,
+		WithOnEvict(func(int, int, EvictReason) { evicted++ }),
+		WithHasher[int, int](func(key int) uint64 { return uint64(key) }),
+	)
+
+	for i := range 8 {
+		cache.Put(i, i)
+	}
+
+	require.True(t, cache.Delete(3))
+	require.False(t, cache.Delete(3))
+	require.Equal(t, 7, cache.Size())
+
+	cache.Clear()
+	require.Equal(t, 0, cache.Size())
+	require.Equal(t, 8, evicted)
+}
+
+func TestEvictReasonString(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "capacity", EvictReasonCapacity.String())
+	require.Equal(t, "deleted", EvictReasonDeleted.String())
+	require.Equal(t, "expired", EvictReasonExpired.String())
+	require.Equal(t, "replaced", EvictReasonReplaced.String())
+	require.Equal(t, "rejected", EvictReasonRejected.String())
+	require.Equal(t, "unknown", EvictReason(42).String())
+}
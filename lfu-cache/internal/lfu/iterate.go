@@ -0,0 +1,244 @@
+package lfu
+
+import (
+	"iter"
+	"slices"
+)
+
+// Entry is the key with its value and frequency, which is yielded by AllWithFrequency and Coldest
+type Entry[K comparable, V any] struct {
+	Key       K
+	Value     V
+	Frequency int
+}
+
+// Function returns iterator over keys of the given iterator
+func keysOf[K comparable, V any](all iter.Seq2[K, V]) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range all {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Function returns iterator over values of the given iterator
+func valuesOf[K comparable, V any](all iter.Seq2[K, V]) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range all {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Function collects entries of the given iterator and returns iterator over at most n last of them in reverse order.
+// It is used by caches, which can't walk their keys from the coldest one
+func coldestOf[K comparable, V any](all iter.Seq[Entry[K, V]], n int) iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		if n <= 0 {
+			return
+		}
+		entries := slices.Collect(all)
+		for i := len(entries) - 1; i >= max(len(entries)-n, 0); i-- {
+			if !yield(entries[i]) {
+				return
+			}
+		}
+	}
+}
+
+// AllWithFrequency works like Cache.AllWithFrequency, expired keys are skipped.
+func (l *cacheImpl[K, V]) AllWithFrequency() iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		for el := range l.allWithFrequency() {
+			if !yield(Entry[K, V]{el.key, el.val, el.frequency}) {
+				return
+			}
+		}
+	}
+}
+
+// Coldest works like Cache.Coldest, classes and their keys are walked from the back, expired keys are skipped.
+//
+// O(n + count of skipped expired keys)
+func (l *cacheImpl[K, V]) Coldest(n int) iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		now := l.now().UnixNano()
+		classes := l.frequencyClasses
+		for classNode := classes.Back(); classNode != nil && n > 0; classNode = classNode.Prev(classes) {
+			lst := classNode.Data.lst
+			for keyNode := lst.Back(); keyNode != nil && n > 0; keyNode = keyNode.Prev(lst) {
+				if isExpired(keyNode, now) { // skips expired key
+					continue
+				}
+				n--
+				if !yield(Entry[K, V]{keyNode.Data.key, keyNode.Data.val, classNode.Data.frequency}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (l *cacheImpl[K, V]) Keys() iter.Seq[K] {
+	return keysOf(l.All())
+}
+
+func (l *cacheImpl[K, V]) Values() iter.Seq[V] {
+	return valuesOf(l.All())
+}
+
+// AllWithFrequency works like Cache.AllWithFrequency, see shardedCache.All for order of keys.
+func (c *shardedCache[K, V]) AllWithFrequency() iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		for el := range c.allWithFrequency() {
+			if !yield(Entry[K, V]{el.key, el.val, el.frequency}) {
+				return
+			}
+		}
+	}
+}
+
+// Coldest works like Cache.Coldest, but the order is the reverse of All, keys are evicted by every shard separately,
+// so the first key is the coldest one of the whole cache, but not necessarily the next victim of its shard.
+func (c *shardedCache[K, V]) Coldest(n int) iter.Seq[Entry[K, V]] {
+	return coldestOf(c.AllWithFrequency(), n)
+}
+
+func (c *shardedCache[K, V]) Keys() iter.Seq[K] {
+	return keysOf(c.All())
+}
+
+func (c *shardedCache[K, V]) Values() iter.Seq[V] {
+	return valuesOf(c.All())
+}
+
+// Coldest works like Cache.Coldest, but the order is the reverse of All.
+// Keys of the window aren't evicted by frequency, so the listed keys are candidates for eviction, not the exact order.
+func (c *tinyLFUCache[K, V]) Coldest(n int) iter.Seq[Entry[K, V]] {
+	return coldestOf(c.AllWithFrequency(), n)
+}
+
+func (c *tinyLFUCache[K, V]) Keys() iter.Seq[K] {
+	return keysOf(c.All())
+}
+
+func (c *tinyLFUCache[K, V]) Values() iter.Seq[V] {
+	return valuesOf(c.All())
+}
+
+// AllWithFrequency works like Cache.AllWithFrequency, keys are listed in the order of use history.
+func (c *lruCache[K, V]) AllWithFrequency() iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		for entry := range c.lst.All() {
+			if !yield(Entry[K, V]{entry.key, entry.val, entry.frequency}) {
+				return
+			}
+		}
+	}
+}
+
+// Coldest works like Cache.Coldest, the least recently used key is listed first regardless of frequency.
+//
+// O(n)
+func (c *lruCache[K, V]) Coldest(n int) iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		for node := c.lst.Back(); node != nil && n > 0; node = node.Prev(c.lst) {
+			n--
+			if !yield(Entry[K, V]{node.Data.key, node.Data.val, node.Data.frequency}) {
+				return
+			}
+		}
+	}
+}
+
+func (c *lruCache[K, V]) Keys() iter.Seq[K] {
+	return keysOf(c.All())
+}
+
+func (c *lruCache[K, V]) Values() iter.Seq[V] {
+	return valuesOf(c.All())
+}
+
+// AllWithFrequency works like Cache.AllWithFrequency, see arcCache.All for order of keys.
+func (c *arcCache[K, V]) AllWithFrequency() iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		for _, list := range []arcList{arcFrequent, arcRecent} {
+			for entry := range c.lists[list].All() {
+				if !yield(Entry[K, V]{entry.key, entry.val, entry.frequency}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Coldest works like Cache.Coldest, but the order is the reverse of All:
+// keys of T1 are listed before keys of T2, the least recently used key of every list is listed first.
+// ARC chooses the list to evict from by its target, so it is the preview of candidates, not the exact order.
+//
+// O(n)
+func (c *arcCache[K, V]) Coldest(n int) iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		for _, list := range []arcList{arcRecent, arcFrequent} {
+			lst := c.lists[list]
+			for node := lst.Back(); node != nil && n > 0; node = node.Prev(lst) {
+				n--
+				if !yield(Entry[K, V]{node.Data.key, node.Data.val, node.Data.frequency}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *arcCache[K, V]) Keys() iter.Seq[K] {
+	return keysOf(c.All())
+}
+
+func (c *arcCache[K, V]) Values() iter.Seq[V] {
+	return valuesOf(c.All())
+}
+
+// AllWithFrequency works like Cache.AllWithFrequency, keys are listed in descending order of priority,
+// but frequency, not priority, is yielded.
+func (c *lfudaCache[K, V]) AllWithFrequency() iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		for _, class := range c.sortedClasses() {
+			for entry := range class.lst.All() {
+				if !yield(Entry[K, V]{entry.key, entry.val, entry.frequency}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Coldest works like Cache.Coldest, the key with the least priority is listed first.
+//
+// O(n + count of classes * log count of classes)
+func (c *lfudaCache[K, V]) Coldest(n int) iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		classes := c.sortedClasses()
+		for i := len(classes) - 1; i >= 0 && n > 0; i-- {
+			lst := classes[i].lst
+			for node := lst.Back(); node != nil && n > 0; node = node.Prev(lst) {
+				n--
+				if !yield(Entry[K, V]{node.Data.key, node.Data.val, node.Data.frequency}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *lfudaCache[K, V]) Keys() iter.Seq[K] {
+	return keysOf(c.All())
+}
+
+func (c *lfudaCache[K, V]) Values() iter.Seq[V] {
+	return valuesOf(c.All())
+}
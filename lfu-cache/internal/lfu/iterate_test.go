@@ -0,0 +1,117 @@
+package lfu
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllWithFrequency(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, int](4)
+
+	cache.Put(1, 10)
+	cache.Put(2, 20)
+	cache.Put(3, 30)
+	_, _ = cache.Get(2)
+	_, _ = cache.Get(2)
+	_, _ = cache.Get(3)
+
+	require.Equal(t, []Entry[int, int]{
+		{2, 20, 3},
+		{3, 30, 2},
+		{1, 10, 1},
+	}, slices.Collect(cache.AllWithFrequency()))
+	require.Equal(t, []int{2, 3, 1}, slices.Collect(cache.Keys()))
+	require.Equal(t, []int{20, 30, 10}, slices.Collect(cache.Values()))
+
+	freq, err := cache.GetKeyFrequency(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, freq)
+}
+
+func TestColdest(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, int](5)
+
+	for i := 1; i <= 5; i++ {
+		cache.Put(i, i)
+	}
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(2)
+	_, _ = cache.Get(2)
+
+	require.Equal(t, []Entry[int, int]{
+		{3, 3, 1},
+		{4, 4, 1},
+		{5, 5, 1},
+	}, slices.Collect(cache.Coldest(3)))
+	keys := make([]int, 0, 5)
+	for entry := range cache.Coldest(100) {
+		keys = append(keys, entry.Key)
+	}
+	require.Equal(t, []int{3, 4, 5, 1, 2}, keys)
+	require.Empty(t, slices.Collect(cache.Coldest(-1)))
+
+	cache.Put(6, 6) // Coldest previews eviction, so 3 is evicted
+	_, err := cache.Get(3)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestColdestSkipsExpired(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(3, withClock[int, int](clock.Now))
+
+	cache.PutWithTTL(1, 1, time.Second)
+	cache.Put(2, 2)
+	cache.Put(3, 3)
+	clock.Advance(time.Second)
+
+	require.Equal(t, []Entry[int, int]{{2, 2, 1}}, slices.Collect(cache.Coldest(1)))
+	require.Len(t, slices.Collect(cache.AllWithFrequency()), 2)
+}
+
+func TestShardedIterators(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded(8, 4, identityHasher())
+	for i := range 4 {
+		for range i + 1 {
+			cache.Put(i, i*10)
+		}
+	}
+
+	entries := slices.Collect(cache.AllWithFrequency())
+	require.Equal(t, []Entry[int, int]{{3, 30, 4}, {2, 20, 3}, {1, 10, 2}, {0, 0, 1}}, entries)
+	require.Equal(t, []int{3, 2, 1, 0}, slices.Collect(cache.Keys()))
+	require.Equal(t, []int{30, 20, 10, 0}, slices.Collect(cache.Values()))
+	require.Equal(t, []Entry[int, int]{{0, 0, 1}, {1, 10, 2}}, slices.Collect(cache.Coldest(2)))
+}
+
+func TestTinyLFUIterators(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTinyLFU(10, identityHasher())
+	for i := range 10 {
+		for range i + 1 {
+			cache.Put(i, i*10)
+		}
+	}
+
+	entries := slices.Collect(cache.AllWithFrequency())
+	require.Len(t, entries, 10)
+	require.Equal(t, Entry[int, int]{9, 90, 10}, entries[0]) // the last key is still in the window
+	keys, values := collect(cache.All())
+	require.Equal(t, keys, slices.Collect(cache.Keys()))
+	require.Equal(t, values, slices.Collect(cache.Values()))
+
+	coldest := slices.Collect(cache.Coldest(3))
+	slices.Reverse(entries)
+	require.Equal(t, entries[:3], coldest)
+}
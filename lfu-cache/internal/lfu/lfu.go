@@ -2,12 +2,16 @@ package lfu
 
 import (
 	"errors"
+	"fmt"
 	"iter"
-	"lfucache/internal/linkedlist"
+	"lfucache/pkg/linkedlist"
+	"time"
 )
 
 var ErrKeyNotFound = errors.New("key not found")
 
+var ErrInvalidCapacity = errors.New("the capacity must not be negative")
+
 const DefaultCapacity = 5
 
 // Cache
@@ -28,12 +32,59 @@ type Cache[K comparable, V any] interface {
 	// O(1)
 	Put(key K, value V)
 
+	// Peek returns the value of the key like Get, but doesn't change frequency and recency of the key
+	// and isn't counted in statistics.
+	//
+	// O(1)
+	Peek(key K) (V, error)
+
+	// Contains reports whether the key exists in the cache without changing its frequency and recency.
+	//
+	// O(1)
+	Contains(key K) bool
+
+	// PutIfAbsent inserts the key like Put, if it isn't present, and reports whether the value was put.
+	// If the key is present, neither its value nor its frequency is changed.
+	//
+	// O(1)
+	PutIfAbsent(key K, value V) bool
+
+	// CompareAndSwapFunc updates the value of the key like Put, if the key is present and eq reports
+	// that its value equals oldValue, and reports whether the value was swapped.
+	// Use CompareAndSwap to compare values by ==.
+	//
+	// O(1)
+	CompareAndSwapFunc(key K, oldValue, newValue V, eq func(a, b V) bool) bool
+
 	// All returns the iterator in descending order of frequency.
 	// If two or more keys have the same frequency, the most recently used key will be listed first.
 	//
 	// O(capacity)
 	All() iter.Seq2[K, V]
 
+	// AllWithFrequency returns the iterator over keys, values and frequencies in the same order as All.
+	// Iterating doesn't change frequencies of keys.
+	//
+	// O(capacity)
+	AllWithFrequency() iter.Seq[Entry[K, V]]
+
+	// Coldest returns the iterator over at most n keys, which would be evicted first, in the order of eviction:
+	// the least frequently used key is listed first, ties are broken by the least recently used key.
+	// Iterating doesn't change frequencies of keys.
+	//
+	// O(n) for cacheImpl, O(capacity) for other implementations
+	Coldest(n int) iter.Seq[Entry[K, V]]
+
+	// Keys returns the iterator over keys in the same order as All.
+	//
+	// O(capacity)
+	Keys() iter.Seq[K]
+
+	// Values returns the iterator over values in the same order as All.
+	//
+	// O(capacity)
+	Values() iter.Seq[V]
+
 	// Size returns the cache size.
 	//
 	// O(1)
@@ -49,27 +100,55 @@ type Cache[K comparable, V any] interface {
 	//
 	// O(1)
 	GetKeyFrequency(key K) (int, error)
+
+	// Stats returns the snapshot of statistics of the cache.
+	//
+	// O(count of different frequencies)
+	Stats() Stats
+
+	// Delete removes the key from the cache and reports whether the key was present.
+	//
+	// O(1)
+	Delete(key K) bool
+
+	// Clear removes all keys from the cache.
+	//
+	// O(capacity)
+	Clear()
 }
 
 // cacheImpl represents LFU cache implementation
 type cacheImpl[K comparable, V any] struct {
-	frequencyClasses internal.LinkedList[*classFrequency[K, V]] // linkedlist where every node is linked list of keys that have the same frequency of use
-	keyToElements    map[K]*internal.Node[valOfKey[K, V]]       // Map from key to node with value
+	frequencyClasses linkedlist.LinkedList[*classFrequency[K, V]] // linkedlist where every node is linked list of keys that have the same frequency of use
+	keyToElements    map[K]*linkedlist.Node[valOfKey[K, V]]       // Map from key to node with value
 	capacity         int
 	size             int
+	ttl              time.Duration                                // time to live of keys added by Put, zero means that keys never expire
+	now              func() time.Time                             // source of current time for checking expiration
+	onEvict          OnEvictFunc[K, V]                            // callback, which is called when the value leaves the cache, may be nil
+	maxFrequency     int                                          // frequency, which can't be exceeded by keys, zero means no limit
+	agingPeriod      int                                          // count of Get and Put calls between halvings of frequencies, zero means no aging
+	opsSinceAging    int                                          // count of Get and Put calls since the last halving
+	costOf           CostFunc[K, V]                               // function computing cost of the key and value, is used only if maxCost is set
+	maxCost          int64                                        // limit of total cost of keys, zero means no limit
+	cost             int64                                        // total cost of keys in the cache
+	stats            statsCounters                                // counters of calls and evictions
+	codec            Codec                                        // format of snapshots
+	freeNodes        linkedlist.LinkedList[valOfKey[K, V]]        // nodes of removed keys, which are reused by Put
+	freeClasses      linkedlist.LinkedList[*classFrequency[K, V]] // empty frequency classes, which are reused
 }
 
 // Auxiliary structure that stores keys in the form of a list in the order of their use history
 // and the frequency of the stored keys
 type classFrequency[K comparable, V any] struct {
-	lst       internal.LinkedList[valOfKey[K, V]]
+	lst       linkedlist.LinkedList[valOfKey[K, V]]
 	frequency int
 }
 
 // Factory of classFrequency, which initializes new class by values
 func newClassFrequency[K comparable, V any](frequency int) *classFrequency[K, V] {
 	cls := new(classFrequency[K, V])
-	cls.lst = internal.NewLinkedList[valOfKey[K, V]]()
+	cls.lst = linkedlist.NewLinkedList[valOfKey[K, V]]()
 	cls.frequency = frequency
 	return cls
 }
@@ -79,7 +158,17 @@ func newClassFrequency[K comparable, V any](frequency int) *classFrequency[K, V]
 type valOfKey[K comparable, V any] struct {
 	key           K
 	val           V
-	nodeFreqClass *internal.Node[*classFrequency[K, V]] // is needed to have opportunity of accessing to nodes of frequencyClasses
+	nodeFreqClass *linkedlist.Node[*classFrequency[K, V]] // is needed to have opportunity of accessing to nodes of frequencyClasses
+	expiresAt     int64                                   // time of expiration in unix nanoseconds, zero means that key never expires
+	cost          int64                                   // cost of key and value, is counted only if maxCost is set
+}
+
+// Structure, which is yielded by iterators that need frequency of the key
+type frequencyEntry[K comparable, V any] struct {
+	key       K
+	val       V
+	frequency int
+	expiresAt int64
 }
 
 // New initializes the cache with the given capacity.
@@ -88,55 +177,187 @@ func New[K comparable, V any](capacity ...int) *cacheImpl[K, V] {
 	newCap := DefaultCapacity
 	if len(capacity) > 0 { // if capacity was given
 		newCap = capacity[0] // reads the value
-		if newCap < 0 {      // if capacity is incorrect, New panics
-			panic("The capacity must be greater than zero")
-		}
 	}
-	classes := internal.NewLinkedList[*classFrequency[K, V]]() // Creates frequencyClasses
-	classes.PushBack(newClassFrequency[K, V](1))               // and pushes in it default frequency class with frequency 1
+	return NewWithOptions[K, V](newCap)
+}
+
+// NewWithOptions initializes the cache with the given capacity and configures it by the given options.
+// If capacity is incorrect, NewWithOptions panics, use TryNew to get error instead.
+func NewWithOptions[K comparable, V any](capacity int, opts ...Option[K, V]) *cacheImpl[K, V] {
+	cache, err := TryNew(capacity, opts...)
+	if err != nil { // if capacity is incorrect, NewWithOptions panics
+		panic(err)
+	}
+	return cache
+}
+
+// TryNew initializes the cache with the given capacity and configures it by the given options.
+// If capacity is negative, TryNew returns ErrInvalidCapacity.
+func TryNew[K comparable, V any](capacity int, opts ...Option[K, V]) (*cacheImpl[K, V], error) {
+	if capacity < 0 { // if capacity is incorrect, returns error
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCapacity, capacity)
+	}
+	o := newOptions(opts)
+	classes := linkedlist.NewLinkedList[*classFrequency[K, V]]() // Creates frequencyClasses
+	classes.PushBack(newClassFrequency[K, V](1))                 // and pushes in it default frequency class with frequency 1
 
-	return &cacheImpl[K, V]{classes, make(map[K]*internal.Node[valOfKey[K, V]], newCap), newCap, 0}
+	return &cacheImpl[K, V]{
+		frequencyClasses: classes,
+		keyToElements:    make(map[K]*linkedlist.Node[valOfKey[K, V]], capacity),
+		capacity:         capacity,
+		ttl:              o.defaultTTL,
+		now:              o.now,
+		onEvict:          o.onEvict,
+		maxFrequency:     o.maxFrequency,
+		agingPeriod:      o.agingPeriod,
+		costOf:           o.costOf,
+		maxCost:          o.maxCost,
+		codec:            o.codec,
+		freeNodes:        linkedlist.NewLinkedList[valOfKey[K, V]](),
+		freeClasses:      linkedlist.NewLinkedList[*classFrequency[K, V]](),
+	}, nil
 }
 
 // Function increases frequency of key in valNode and moves valNode to frequency class of new frequency
 // or if there is no this class, create it, and moves valNode in its
-func (l *cacheImpl[K, V]) increaseFreqOfKey(keyNode *internal.Node[valOfKey[K, V]]) {
+func (l *cacheImpl[K, V]) increaseFreqOfKey(keyNode *linkedlist.Node[valOfKey[K, V]]) {
 	// reads values from pointer of Node to won't have long access to memory in method
 	curFreqNode := keyNode.Data.nodeFreqClass // node of frequencyClasses containing keyNode
 	curClass := curFreqNode.Data              // classFrequency of curFreqNode
 	curLst := curClass.lst                    // linkedList of keys with one frequency
 	curFraq := curClass.frequency             // current frequency
 
+	if curFraq == l.maxFrequency { // if frequency has reached its limit, key only becomes the most recently used one
+		curLst.MoveToFront(keyNode, curLst)
+		return
+	}
 	if nextClass := curFreqNode.Prev(l.frequencyClasses); nextClass != nil && nextClass.Data.frequency == curFraq+1 { // if there is frequency class of new frequency (nextClass)
 		nextClass.Data.lst.MoveToFront(keyNode, curLst) // moves keyNode to this nextClass
 		keyNode.Data.nodeFreqClass = nextClass
 	} else if curLst.Size() == 1 { // if nextClass doesn't exist, but in curClass there is only keyNode
 		curClass.frequency++ // changes frequency of curClass
 	} else { // if nextClass doesn't exist and in curClass there are another nodes
-		newClass := newClassFrequency[K, V](curFraq + 1) // creates new class
-		newClass.lst.MoveToFront(keyNode, curLst)        // moves keyNode to new class
-		keyNode.Data.nodeFreqClass = l.frequencyClasses.PushBefore(curFreqNode, newClass)
+		newClass := l.pushClass(curFraq+1, curFreqNode) // creates new class or reuses free one
+		newClass.Data.lst.MoveToFront(keyNode, curLst)  // moves keyNode to new class
+		keyNode.Data.nodeFreqClass = newClass
 	}
 	if curLst.Size() == 0 { // if after moving node its last class (curClass) has become empty
 		// remove curClass
-		l.frequencyClasses.Remove(curFreqNode)
+		l.releaseClass(curFreqNode)
+	}
+}
+
+// Function returns the time of expiration of key added now with the given ttl in unix nanoseconds
+func (l *cacheImpl[K, V]) expiration(ttl time.Duration) int64 {
+	if ttl <= 0 { // if key must not expire
+		return 0
+	}
+	return l.now().Add(ttl).UnixNano()
+}
+
+// Function checks whether keyNode has expired at the moment now given in unix nanoseconds
+func isExpired[K comparable, V any](keyNode *linkedlist.Node[valOfKey[K, V]], now int64) bool {
+	expiresAt := keyNode.Data.expiresAt
+	return expiresAt != 0 && expiresAt <= now
+}
+
+// Function checks whether keyNode has already expired, time is read only if key has expiration
+func (l *cacheImpl[K, V]) expired(keyNode *linkedlist.Node[valOfKey[K, V]]) bool {
+	return keyNode.Data.expiresAt != 0 && isExpired(keyNode, l.now().UnixNano())
+}
+
+// Function returns node of alive key or nil, if there isn't key. If key has expired, function removes it
+func (l *cacheImpl[K, V]) lookup(key K) *linkedlist.Node[valOfKey[K, V]] {
+	keyNode, ok := l.keyToElements[key] // attempt to read key from keyToElements
+	if !ok {                            // if there isn't given key
+		return nil
+	}
+	if l.expired(keyNode) { // if key is in the cache, but it has expired, lazily removes it
+		l.removeNode(keyNode, EvictReasonExpired)
+		return nil
+	}
+	return keyNode
+}
+
+// Function removes keyNode from its frequency class and map. If the class becomes empty, it is removed too,
+// except the case when it is the only class in frequencyClasses
+func (l *cacheImpl[K, V]) removeNode(keyNode *linkedlist.Node[valOfKey[K, V]], reason EvictReason) {
+	removed := keyNode.Data // node is zeroed, when it becomes free
+	freqNode := removed.nodeFreqClass
+	l.releaseKey(keyNode, freqNode.Data.lst)
+	delete(l.keyToElements, removed.key)
+	l.size--
+	l.cost -= removed.cost
+	if freqNode.Data.lst.Size() == 0 && l.frequencyClasses.Size() > 1 { // if class has become empty and it isn't the last class
+		l.releaseClass(freqNode)
+	}
+	l.notifyEvict(removed.key, removed.val, reason)
+}
+
+// Function returns the key, which will be evicted by Put of new key into the filled cache,
+// or false, if the cache is empty
+func (l *cacheImpl[K, V]) victim() (K, bool) {
+	if back := l.frequencyClasses.Back().Data.lst.Back(); back != nil {
+		return back.Data.key, true
+	}
+	var zeroKey K
+	return zeroKey, false
+}
+
+// Function counts removing of the value and calls onEvict callback, if it is set
+func (l *cacheImpl[K, V]) notifyEvict(key K, value V, reason EvictReason) {
+	l.stats.countEviction(reason)
+	if l.onEvict != nil {
+		l.onEvict(key, value, reason)
 	}
 }
 
 func (l *cacheImpl[K, V]) Get(key K) (V, error) {
-	valueOfKey, ok := l.keyToElements[key] // attempt to read key from keyToElements
-	if !ok {                               // if there isn't given key
+	valueOfKey := l.lookup(key)
+	if valueOfKey == nil { // if there isn't given key
+		l.stats.misses.Add(1)
 		var zeroVal V
 		return zeroVal, ErrKeyNotFound // returns error
 	}
+	l.stats.hits.Add(1)
 	l.increaseFreqOfKey(valueOfKey) // increases frequency of this key
+	l.tick()
 	return valueOfKey.Data.val, nil
 }
 
+// Put works like Cache.Put, key expires after default ttl of the cache, if it is set.
 func (l *cacheImpl[K, V]) Put(key K, value V) {
-	if valNode, ok := l.keyToElements[key]; ok { // if already there is given key in cacheImpl
-		valNode.Data.val = value     // changes value of key
+	l.put(key, value, l.ttl)
+}
+
+// PutWithTTL works like Put, but key expires after the given ttl instead of the default one.
+// Non-positive ttl means that key never expires.
+//
+// O(1)
+func (l *cacheImpl[K, V]) PutWithTTL(key K, value V, ttl time.Duration) {
+	l.put(key, value, ttl)
+}
+
+// Function inserts or updates key with the given ttl
+func (l *cacheImpl[K, V]) put(key K, value V, ttl time.Duration) {
+	l.stats.puts.Add(1)
+	expiresAt := l.expiration(ttl)
+	var cost int64
+	if l.maxCost > 0 { // if cost is limited, makes place for the value
+		cost = max(l.costOf(key, value), 0)
+		if !l.reserveCost(key, value, cost) { // if value can't fit in the cache at all
+			return
+		}
+	}
+	if valNode := l.lookup(key); valNode != nil { // if already there is given alive key in cacheImpl
+		oldValue := valNode.Data.val
+		valNode.Data.val = value           // changes value of key
+		valNode.Data.expiresAt = expiresAt // and its time of expiration
+		l.cost += cost - valNode.Data.cost // and its cost
+		valNode.Data.cost = cost
 		l.increaseFreqOfKey(valNode) // increases frequency of key
+		l.notifyEvict(key, oldValue, EvictReasonReplaced)
+		l.tick()
 		return
 	}
 	// reads values from pointer of Node, to won't have long access to memory in method
@@ -145,27 +366,47 @@ func (l *cacheImpl[K, V]) Put(key K, value V) {
 	leastFreqLst := leastFreqClass.lst
 
 	if l.Size() == l.Capacity() { // if cache is filled
+		if l.size == 0 { // if capacity is zero, nothing can be put
+			l.notifyEvict(key, value, EvictReasonRejected)
+			return
+		}
 		// removes key with least frequency and the oldest time of using
-		delete(l.keyToElements, leastFreqLst.PopBack().Data.key) // from leastFreqClass and map
+		evictedNode := leastFreqLst.Back()
+		evicted := evictedNode.Data
+		l.releaseKey(evictedNode, leastFreqLst)
+		delete(l.keyToElements, evicted.key) // from leastFreqClass and map
+		l.cost -= evicted.cost
+		l.notifyEvict(evicted.key, evicted.val, EvictReasonCapacity)
 	} else {
 		l.size++ // increments size
 	}
 	if leastFreqClass.frequency > 1 { // if class of least frequency (leastFreqClass) has frequency bigger then 1
 		if leastFreqLst.Size() > 0 { // if leastFreqClass is not empty, a new one is created
-			leastFreqClass = newClassFrequency[K, V](1)
-			freqClasses.PushBack(leastFreqClass)
+			leastFreqClass = l.pushClass(1, nil).Data
 		} else { // if this class is empty
 			leastFreqClass.frequency = 1 // reusing this class with changing its frequency
 		}
 	}
 	// adds this key in map and in leastFreqClass
-	l.keyToElements[key] = leastFreqClass.lst.PushFront(valOfKey[K, V]{key, value, freqClasses.Back()})
+	l.keyToElements[key] = l.pushKeyFront(leastFreqClass.lst, valOfKey[K, V]{key, value, freqClasses.Back(), expiresAt, cost})
+	l.cost += cost
+	l.tick()
 }
 
+// All works like Cache.All, expired keys are skipped.
 func (l *cacheImpl[K, V]) All() iter.Seq2[K, V] {
 	return func(yield func(K, V) bool) {
+		var now int64                                 // current time is read only when the first key with expiration is met
 		for class := range l.frequencyClasses.All() { // iterates by classes of frequency
 			for el := range class.lst.All() { // iterates by keys in current class of frequency
+				if el.expiresAt != 0 {
+					if now == 0 {
+						now = l.now().UnixNano()
+					}
+					if el.expiresAt <= now { // skips expired key
+						continue
+					}
+				}
 				if !yield(el.key, el.val) { // checks that user wants next value
 					return
 				}
@@ -174,6 +415,24 @@ func (l *cacheImpl[K, V]) All() iter.Seq2[K, V] {
 	}
 }
 
+// Function returns iterator over alive keys with their frequencies in the same order as All
+func (l *cacheImpl[K, V]) allWithFrequency() iter.Seq[frequencyEntry[K, V]] {
+	return func(yield func(frequencyEntry[K, V]) bool) {
+		now := l.now().UnixNano()
+		for class := range l.frequencyClasses.All() { // iterates by classes of frequency
+			for el := range class.lst.All() {
+				if el.expiresAt != 0 && el.expiresAt <= now { // skips expired key
+					continue
+				}
+				if !yield(frequencyEntry[K, V]{el.key, el.val, class.frequency, el.expiresAt}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Size works like Cache.Size, expired keys, which haven't been removed yet, are counted.
 func (l *cacheImpl[K, V]) Size() int {
 	return l.size
 }
@@ -183,9 +442,64 @@ func (l *cacheImpl[K, V]) Capacity() int {
 }
 
 func (l *cacheImpl[K, V]) GetKeyFrequency(key K) (int, error) {
-	val, ok := l.keyToElements[key] // attempt to read key from keyToElements
-	if !ok {                        // if there isn't given key
+	val := l.lookup(key)
+	if val == nil { // if there isn't given key
 		return 0, ErrKeyNotFound // returns error
 	}
 	return val.Data.nodeFreqClass.Data.frequency, nil
 }
+
+// DeleteExpired removes all expired keys from the cache and returns their count.
+//
+// O(capacity)
+func (l *cacheImpl[K, V]) DeleteExpired() int {
+	now := l.now().UnixNano()
+	removed := 0
+	for classNode := l.frequencyClasses.Front(); classNode != nil; { // iterates by classes of frequency
+		nextClass := classNode.Next(l.frequencyClasses) // reads next class before possible removing of current one
+		lst := classNode.Data.lst
+		for keyNode := lst.Front(); keyNode != nil; {
+			nextKey := keyNode.Next(lst) // reads next node before possible removing of current one
+			if isExpired(keyNode, now) {
+				l.removeNode(keyNode, EvictReasonExpired)
+				removed++
+			}
+			keyNode = nextKey
+		}
+		classNode = nextClass
+	}
+	return removed
+}
+
+func (l *cacheImpl[K, V]) Delete(key K) bool {
+	keyNode := l.lookup(key)
+	if keyNode == nil { // if there isn't given alive key
+		return false
+	}
+	l.removeNode(keyNode, EvictReasonDeleted)
+	return true
+}
+
+// Clear works like Cache.Clear, every removed key is counted in Stats and passed to onEvict callback
+// with EvictReasonExpired for expired keys and EvictReasonDeleted for others.
+func (l *cacheImpl[K, V]) Clear() {
+	old := l.frequencyClasses
+	classes := linkedlist.NewLinkedList[*classFrequency[K, V]]() // Creates new frequencyClasses
+	classes.PushBack(newClassFrequency[K, V](1))                 // like New does
+	l.frequencyClasses = classes
+	l.keyToElements = make(map[K]*linkedlist.Node[valOfKey[K, V]], l.capacity)
+	l.size = 0
+	l.cost = 0
+	l.dropFree() // the cache may stay empty for long, so free nodes aren't kept
+
+	now := l.now().UnixNano()
+	for class := range old.All() { // iterates by removed keys, expired ones are counted even without callback
+		for el := range class.lst.All() {
+			reason := EvictReasonDeleted
+			if el.expiresAt != 0 && el.expiresAt <= now {
+				reason = EvictReasonExpired
+			}
+			l.notifyEvict(el.key, el.val, reason)
+		}
+	}
+}
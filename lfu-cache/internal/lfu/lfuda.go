@@ -0,0 +1,253 @@
+package lfu
+
+import (
+	"cmp"
+	"iter"
+	"lfucache/pkg/linkedlist"
+	"slices"
+)
+
+// lfudaCache represents LFU cache with dynamic aging. Every key has priority equal to its frequency
+// plus age of the cache at the moment of the last use, the key with the least priority is evicted first,
+// ties are broken by recency. Age of the cache becomes priority of the evicted key, so new keys start
+// at the level of the keys, which live in the cache now, and keys, which were popular long ago, are finally evicted.
+//
+// Priority of the used key may jump over any count of other priorities, so classes are kept in the min-heap,
+// and Get, Put and Delete take O(log count of different priorities) instead of O(1)
+type lfudaCache[K comparable, V any] struct {
+	policyBase[K, V]
+	classes map[int]*priorityClass[K, V]             // Map from priority to class with keys of this priority
+	heap    []*priorityClass[K, V]                   // min-heap of classes by priority, the root contains the victim
+	keys    map[K]*linkedlist.Node[lfudaEntry[K, V]] // Map from key to node with value
+	size    int
+	age     int // priority of the last evicted key
+}
+
+// Auxiliary structure that stores keys with the same priority in the order of their use history
+type priorityClass[K comparable, V any] struct {
+	lst      linkedlist.LinkedList[lfudaEntry[K, V]]
+	priority int
+	index    int // position of the class in the heap
+}
+
+// Structure that is contained by the node of priorityClass
+type lfudaEntry[K comparable, V any] struct {
+	key       K
+	val       V
+	frequency int                  // count of Get and Put of the key since it has been put
+	class     *priorityClass[K, V] // class containing the key
+}
+
+// Factory of lfudaCache
+func newLFUDA[K comparable, V any](capacity int, onEvict OnEvictFunc[K, V]) *lfudaCache[K, V] {
+	return &lfudaCache[K, V]{
+		policyBase: policyBase[K, V]{capacity: capacity, onEvict: onEvict},
+		classes:    make(map[int]*priorityClass[K, V]),
+		keys:       make(map[K]*linkedlist.Node[lfudaEntry[K, V]], capacity),
+	}
+}
+
+// Function returns class with the given priority, creating it if necessary
+//
+// O(log count of classes)
+func (c *lfudaCache[K, V]) classOf(priority int) *priorityClass[K, V] {
+	if class, ok := c.classes[priority]; ok { // if class already exists
+		return class
+	}
+	class := &priorityClass[K, V]{
+		lst:      linkedlist.NewLinkedList[lfudaEntry[K, V]](),
+		priority: priority,
+		index:    len(c.heap),
+	}
+	c.classes[priority] = class
+	c.heap = append(c.heap, class)
+	c.siftUp(class.index)
+	return class
+}
+
+// Function removes class, if it has become empty
+//
+// O(log count of classes)
+func (c *lfudaCache[K, V]) removeIfEmpty(class *priorityClass[K, V]) {
+	if class.lst.Size() != 0 {
+		return
+	}
+	delete(c.classes, class.priority)
+	i, last := class.index, len(c.heap)-1
+	c.swap(i, last) // the last class takes place of the removed one and is moved to its own place
+	c.heap[last] = nil
+	c.heap = c.heap[:last]
+	if i < last {
+		c.siftDown(i)
+		c.siftUp(i)
+	}
+}
+
+// Function swaps two classes in the heap
+func (c *lfudaCache[K, V]) swap(i, j int) {
+	c.heap[i], c.heap[j] = c.heap[j], c.heap[i]
+	c.heap[i].index = i
+	c.heap[j].index = j
+}
+
+// Function moves the class at position i to the root, while its priority is less than priority of the parent
+func (c *lfudaCache[K, V]) siftUp(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if c.heap[parent].priority <= c.heap[i].priority {
+			return
+		}
+		c.swap(i, parent)
+		i = parent
+	}
+}
+
+// Function moves the class at position i to the leaves, while its priority is greater than priority of a child
+func (c *lfudaCache[K, V]) siftDown(i int) {
+	for {
+		least := i
+		for _, child := range [2]int{2*i + 1, 2*i + 2} {
+			if child < len(c.heap) && c.heap[child].priority < c.heap[least].priority {
+				least = child
+			}
+		}
+		if least == i {
+			return
+		}
+		c.swap(i, least)
+		i = least
+	}
+}
+
+// Function returns classes in descending order of priority
+//
+// O(count of classes * log count of classes)
+func (c *lfudaCache[K, V]) sortedClasses() []*priorityClass[K, V] {
+	classes := slices.Clone(c.heap)
+	slices.SortFunc(classes, func(a, b *priorityClass[K, V]) int {
+		return cmp.Compare(b.priority, a.priority)
+	})
+	return classes
+}
+
+// Function increases frequency of key, recomputes its priority with the current age and moves it to the new class
+//
+// O(log count of classes)
+func (c *lfudaCache[K, V]) touch(node *linkedlist.Node[lfudaEntry[K, V]]) {
+	node.Data.frequency++
+	oldClass := node.Data.class
+	newClass := c.classOf(c.age + node.Data.frequency)
+	newClass.lst.MoveToFront(node, oldClass.lst)
+	node.Data.class = newClass
+	c.removeIfEmpty(oldClass)
+}
+
+// Function removes node from its class and map
+func (c *lfudaCache[K, V]) removeNode(node *linkedlist.Node[lfudaEntry[K, V]], reason EvictReason) {
+	class := node.Data.class
+	class.lst.Remove(node)
+	c.removeIfEmpty(class)
+	delete(c.keys, node.Data.key)
+	c.size--
+	c.notifyEvict(node.Data.key, node.Data.val, reason)
+}
+
+func (c *lfudaCache[K, V]) Get(key K) (V, error) {
+	node, ok := c.keys[key]
+	c.countGet(ok)
+	if !ok {
+		var zeroVal V
+		return zeroVal, ErrKeyNotFound
+	}
+	c.touch(node)
+	return node.Data.val, nil
+}
+
+// Put works like Cache.Put, but the key with the least priority is evicted, and age of the cache becomes its priority.
+func (c *lfudaCache[K, V]) Put(key K, value V) {
+	c.stats.puts.Add(1)
+	if node, ok := c.keys[key]; ok { // if key is present, updates it like Get
+		oldValue := node.Data.val
+		node.Data.val = value
+		c.touch(node)
+		c.notifyEvict(key, oldValue, EvictReasonReplaced)
+		return
+	}
+	if c.capacity == 0 { // if there is no place at all
+		c.notifyEvict(key, value, EvictReasonRejected)
+		return
+	}
+	if c.size == c.capacity { // if cache is filled, evicts the least recently used key with the least priority
+		victim := c.heap[0]
+		c.age = victim.priority
+		c.removeNode(victim.lst.Back(), EvictReasonCapacity)
+	}
+	class := c.classOf(c.age + 1)
+	c.keys[key] = class.lst.PushFront(lfudaEntry[K, V]{key, value, 1, class})
+	c.size++
+}
+
+// All returns the iterator in descending order of priority.
+// If two or more keys have the same priority, the most recently used key will be listed first.
+//
+// O(capacity + count of classes * log count of classes)
+func (c *lfudaCache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, class := range c.sortedClasses() {
+			for entry := range class.lst.All() {
+				if !yield(entry.key, entry.val) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *lfudaCache[K, V]) Size() int {
+	return c.size
+}
+
+// GetKeyFrequency works like Cache.GetKeyFrequency, it returns count of uses of the key, not its priority.
+func (c *lfudaCache[K, V]) GetKeyFrequency(key K) (int, error) {
+	if node, ok := c.keys[key]; ok {
+		return node.Data.frequency, nil
+	}
+	return 0, ErrKeyNotFound
+}
+
+func (c *lfudaCache[K, V]) Delete(key K) bool {
+	node, ok := c.keys[key]
+	if !ok {
+		return false
+	}
+	c.removeNode(node, EvictReasonDeleted)
+	return true
+}
+
+// Clear works like Cache.Clear, age of the cache is reset too.
+func (c *lfudaCache[K, V]) Clear() {
+	old := c.sortedClasses()
+	c.classes = make(map[int]*priorityClass[K, V])
+	c.heap = nil
+	c.keys = make(map[K]*linkedlist.Node[lfudaEntry[K, V]], c.capacity)
+	c.size = 0
+	c.age = 0
+	for _, class := range old {
+		for entry := range class.lst.All() {
+			c.notifyEvict(entry.key, entry.val, EvictReasonDeleted)
+		}
+	}
+}
+
+func (c *lfudaCache[K, V]) Stats() Stats {
+	stats := c.stats.snapshot()
+	stats.Size = c.size
+	stats.FrequencyHistogram = make(map[int]int)
+	for _, class := range c.heap {
+		for entry := range class.lst.All() {
+			stats.FrequencyHistogram[entry.frequency]++
+		}
+	}
+	stats.FrequencyClasses = len(stats.FrequencyHistogram)
+	return stats
+}
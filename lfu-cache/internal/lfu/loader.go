@@ -0,0 +1,153 @@
+package lfu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrLoaderPanicked is returned by GetOrLoad, when loader has panicked
+var ErrLoaderPanicked = errors.New("loader panicked")
+
+// LoaderFunc loads the value of the key, which is missing in the cache, for example, from the database
+type LoaderFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Auxiliary structure, which represents one running load of the key shared by all callers waiting for it
+type loadCall[V any] struct {
+	done    chan struct{}      // is closed, when val and err are set
+	val     V                  // loaded value
+	err     error              // error of loader
+	waiters int                // count of callers waiting for the load, is guarded by mutex of shard
+	cancel  context.CancelFunc // cancels context of loader, when all callers have stopped waiting
+	stale   bool               // loaded value isn't stored, because the load is abandoned or the key is changed, is guarded by mutex of shard
+}
+
+// Auxiliary structure, which stores cached error of loader
+type loadFailure struct {
+	err       error
+	expiresAt int64 // time of expiration in unix nanoseconds
+}
+
+// GetOrLoad returns the value of the key, if it is in the cache. Otherwise, it calls loader, puts loaded value
+// in the cache and returns it. Concurrent calls for the same missing key share one call of loader.
+// If WithNegativeTTL is set, error of loader is returned for the key without calling loader, until the ttl passes.
+//
+// When ctx is done, GetOrLoad returns ctx.Err() without waiting for loader. Loader runs with the context,
+// which is canceled only when all callers waiting for the key have gone, so the load isn't wasted for others.
+func (c *shardedCache[K, V]) GetOrLoad(ctx context.Context, key K, loader LoaderFunc[K, V]) (V, error) {
+	var zeroVal V
+	if err := ctx.Err(); err != nil { // if caller has already gone
+		return zeroVal, err
+	}
+
+	s := c.shardOf(key)
+	s.mu.Lock()
+	if val, err := s.cache.Get(key); err == nil { // if key is in the cache
+		s.mu.Unlock()
+		return val, nil
+	}
+	if failure, ok := s.failures[key]; ok { // if loader has recently failed on this key
+		if failure.expiresAt > c.now().UnixNano() {
+			s.mu.Unlock()
+			return zeroVal, failure.err
+		}
+		delete(s.failures, key)
+	}
+	call, ok := s.inflight[key]
+	if !ok { // if nobody loads this key, starts the load
+		call = c.startLoad(ctx, s, key, loader)
+	}
+	call.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.val, call.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 { // if nobody waits for the load, it is canceled
+			call.cancel()
+			s.abandonLoad(key, call) // so that new callers start a new load instead of joining the canceled one
+		}
+		s.mu.Unlock()
+		return zeroVal, ctx.Err()
+	}
+}
+
+// Function starts goroutine running loader and registers it in the shard. Mutex of shard must be held
+func (c *shardedCache[K, V]) startLoad(ctx context.Context, s *shard[K, V], key K, loader LoaderFunc[K, V]) *loadCall[V] {
+	loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx)) // keeps values of ctx, but not its cancellation
+	call := &loadCall[V]{done: make(chan struct{}), cancel: cancel}
+	if s.inflight == nil {
+		s.inflight = make(map[K]*loadCall[V])
+	}
+	s.inflight[key] = call
+
+	go func() {
+		defer cancel()
+		val, err := runLoader(loadCtx, key, loader)
+
+		s.mu.Lock()
+		if s.inflight[key] == call { // the key may be already loaded by a newer call
+			delete(s.inflight, key)
+		}
+		if !call.stale { // stale value is outdated by Put or Delete, or nobody waits for it
+			if err == nil { // loaded value is put in the cache, so it takes part in eviction like any other key
+				s.cache.Put(key, val)
+			} else if c.negTTL > 0 && loadCtx.Err() == nil { // error is cached, if loader wasn't canceled
+				if s.failures == nil {
+					s.failures = make(map[K]loadFailure)
+				}
+				s.failures[key] = loadFailure{err, c.now().Add(c.negTTL).UnixNano()}
+			}
+		}
+		call.val, call.err = val, err
+		s.mu.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+// Function calls loader and converts its panic to error
+func runLoader[K comparable, V any](ctx context.Context, key K, loader LoaderFunc[K, V]) (val V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrLoaderPanicked, r)
+		}
+	}()
+	return loader(ctx, key)
+}
+
+// Function removes cached error of loader for the key and marks its running load as stale, so that the load
+// doesn't overwrite the newer value or restore the deleted key. Mutex of shard must be held
+func (s *shard[K, V]) forgetKey(key K) {
+	delete(s.failures, key)
+	if call, ok := s.inflight[key]; ok {
+		call.stale = true
+	}
+}
+
+// Function marks all running loads of the shard as stale, it is used, when the whole shard is replaced. Mutex of shard must be held
+func (s *shard[K, V]) forgetLoads() {
+	for _, call := range s.inflight {
+		call.stale = true
+	}
+}
+
+// Function unregisters the canceled load of the key, which nobody waits for. Mutex of shard must be held
+func (s *shard[K, V]) abandonLoad(key K, call *loadCall[V]) {
+	call.stale = true
+	if s.inflight[key] == call {
+		delete(s.inflight, key)
+	}
+}
+
+// Function removes cached errors of loader, which have expired at the moment now. Mutex of shard must be held
+func (s *shard[K, V]) deleteExpiredFailures(now int64) {
+	for key, failure := range s.failures {
+		if failure.expiresAt <= now {
+			delete(s.failures, key)
+		}
+	}
+}
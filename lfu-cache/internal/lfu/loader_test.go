@@ -0,0 +1,314 @@
+package lfu
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errBackend = errors.New("backend is unavailable")
+
+func TestGetOrLoadCachesValue(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](4, 2)
+	var calls atomic.Int32
+	loader := func(_ context.Context, key int) (int, error) {
+		calls.Add(1)
+		return key * 10, nil
+	}
+
+	for range 3 {
+		v, err := cache.GetOrLoad(context.Background(), 1, loader)
+		require.NoError(t, err)
+		require.Equal(t, 10, v)
+	}
+	require.Equal(t, int32(1), calls.Load())
+
+	freq, err := cache.GetKeyFrequency(1)
+	require.NoError(t, err)
+	require.Equal(t, 3, freq)
+}
+
+func TestGetOrLoadSingleflight(t *testing.T) {
+	t.Parallel()
+
+	const callers = 32
+	cache := NewSharded[int, int](4, 2)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(_ context.Context, key int) (int, error) {
+		calls.Add(1)
+		<-release
+		return key, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := cache.GetOrLoad(context.Background(), 7, loader)
+			require.NoError(t, err)
+			results[i] = v
+		}()
+	}
+
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), calls.Load())
+	for _, v := range results {
+		require.Equal(t, 7, v)
+	}
+}
+
+func TestGetOrLoadNegativeTTL(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewSharded(4, 2, WithNegativeTTL[int, int](time.Minute), withClock[int, int](clock.Now))
+	var calls atomic.Int32
+	loader := func(context.Context, int) (int, error) {
+		calls.Add(1)
+		return 0, errBackend
+	}
+
+	_, err := cache.GetOrLoad(context.Background(), 1, loader)
+	require.ErrorIs(t, err, errBackend)
+	_, err = cache.GetOrLoad(context.Background(), 1, loader)
+	require.ErrorIs(t, err, errBackend)
+	require.Equal(t, int32(1), calls.Load())
+
+	clock.Advance(time.Minute)
+
+	_, err = cache.GetOrLoad(context.Background(), 1, loader)
+	require.ErrorIs(t, err, errBackend)
+	require.Equal(t, int32(2), calls.Load())
+
+	cache.Put(1, 42) // explicit Put forgets the error
+	v, err := cache.GetOrLoad(context.Background(), 1, loader)
+	require.NoError(t, err)
+	require.Equal(t, 42, v)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestGetOrLoadErrorsNotCachedByDefault(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](4, 2)
+	var calls atomic.Int32
+	loader := func(context.Context, int) (int, error) {
+		if calls.Add(1) == 1 {
+			return 0, errBackend
+		}
+		return 5, nil
+	}
+
+	_, err := cache.GetOrLoad(context.Background(), 1, loader)
+	require.ErrorIs(t, err, errBackend)
+	v, err := cache.GetOrLoad(context.Background(), 1, loader)
+	require.NoError(t, err)
+	require.Equal(t, 5, v)
+}
+
+func TestGetOrLoadContextCancellation(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](4, 2)
+	loaderCanceled := make(chan struct{})
+	started := make(chan struct{})
+	loader := func(ctx context.Context, _ int) (int, error) {
+		close(started)
+		<-ctx.Done()
+		close(loaderCanceled)
+		return 0, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := cache.GetOrLoad(ctx, 1, loader)
+		errs <- err
+	}()
+
+	<-started
+	cancel()
+
+	require.ErrorIs(t, <-errs, context.Canceled)
+	select {
+	case <-loaderCanceled:
+	case <-time.After(time.Second):
+		t.Fatal("loader wasn't canceled after the last caller had gone")
+	}
+
+	_, err := cache.GetOrLoad(ctx, 1, loader)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestGetOrLoadSurvivesCancellationOfOneCaller(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](4, 2)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	loader := func(ctx context.Context, key int) (int, error) {
+		close(started)
+		<-release
+		return key, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := cache.GetOrLoad(ctx, 3, loader)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan error)
+	go func() {
+		_, err := cache.GetOrLoad(context.Background(), 3, loader)
+		second <- err
+	}()
+	require.Eventually(t, func() bool {
+		s := cache.shardOf(3)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.inflight[3].waiters == 2
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-first, context.Canceled)
+
+	close(release)
+	require.NoError(t, <-second)
+
+	v, err := cache.Get(3)
+	require.NoError(t, err)
+	require.Equal(t, 3, v)
+}
+
+func TestGetOrLoadPanic(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](4, 2)
+
+	_, err := cache.GetOrLoad(context.Background(), 1, func(context.Context, int) (int, error) {
+		panic("boom")
+	})
+	require.ErrorIs(t, err, ErrLoaderPanicked)
+	require.ErrorContains(t, err, "boom")
+}
+
+func TestGetOrLoadEvicts(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](2, 1)
+	loader := func(_ context.Context, key int) (int, error) { return key, nil }
+
+	for i := range 3 {
+		_, err := cache.GetOrLoad(context.Background(), i, loader)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2, cache.Size())
+	_, err := cache.Get(0)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestGetOrLoadRestartsAbandonedLoad(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](4, 2)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+	loader := func(_ context.Context, key int) (int, error) {
+		if calls.Add(1) == 1 { // the first load ignores cancellation and hangs
+			close(started)
+			<-release
+			return key, nil
+		}
+		return key * 10, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := cache.GetOrLoad(ctx, 1, loader)
+		errs <- err
+	}()
+	<-started
+	cancel()
+	require.ErrorIs(t, <-errs, context.Canceled)
+
+	v, err := cache.GetOrLoad(context.Background(), 1, loader) // doesn't join the canceled load
+	require.NoError(t, err)
+	require.Equal(t, 10, v)
+	require.Equal(t, int32(2), calls.Load())
+
+	close(release)
+	require.Eventually(t, func() bool {
+		s := cache.shardOf(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.inflight) == 0
+	}, time.Second, time.Millisecond)
+	v, err = cache.Get(1) // the abandoned load doesn't overwrite the value
+	require.NoError(t, err)
+	require.Equal(t, 10, v)
+}
+
+func TestGetOrLoadDoesNotOverwriteChanges(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name   string
+		change func(Cache[int, int])
+		found  bool // the key is in the cache after the load
+	}{
+		{"put", func(c Cache[int, int]) { c.Put(1, 2) }, true},
+		{"delete", func(c Cache[int, int]) { c.Delete(1) }, false},
+		{"clear", func(c Cache[int, int]) { c.Clear() }, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := NewSharded[int, int](4, 2)
+			release := make(chan struct{})
+			started := make(chan struct{})
+			loader := func(context.Context, int) (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			}
+
+			loaded := make(chan int)
+			go func() {
+				v, err := cache.GetOrLoad(context.Background(), 1, loader)
+				require.NoError(t, err)
+				loaded <- v
+			}()
+			<-started
+			tc.change(cache)
+			close(release)
+			require.Equal(t, 1, <-loaded) // the caller gets the loaded value, but it isn't put in the cache
+
+			v, err := cache.Get(1)
+			if !tc.found {
+				require.ErrorIs(t, err, ErrKeyNotFound)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 2, v)
+		})
+	}
+}
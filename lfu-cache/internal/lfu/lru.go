@@ -0,0 +1,119 @@
+package lfu
+
+import (
+	"iter"
+	"lfucache/pkg/linkedlist"
+)
+
+// lruCache represents LRU cache implementation, the least recently used key is evicted first
+type lruCache[K comparable, V any] struct {
+	policyBase[K, V]
+	lst  linkedlist.LinkedList[lruEntry[K, V]]  // keys in the order of their use history, the most recently used key is the first
+	keys map[K]*linkedlist.Node[lruEntry[K, V]] // Map from key to node with value
+}
+
+// Structure that is contained by the node of lruCache
+type lruEntry[K comparable, V any] struct {
+	key       K
+	val       V
+	frequency int // count of Get and Put of the key since it has been put, it doesn't affect eviction
+}
+
+// Factory of lruCache
+func newLRU[K comparable, V any](capacity int, onEvict OnEvictFunc[K, V]) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		policyBase: policyBase[K, V]{capacity: capacity, onEvict: onEvict},
+		lst:        linkedlist.NewLinkedList[lruEntry[K, V]](),
+		keys:       make(map[K]*linkedlist.Node[lruEntry[K, V]], capacity),
+	}
+}
+
+func (c *lruCache[K, V]) Get(key K) (V, error) {
+	node, ok := c.keys[key]
+	c.countGet(ok)
+	if !ok {
+		var zeroVal V
+		return zeroVal, ErrKeyNotFound
+	}
+	node.Data.frequency++
+	c.lst.MoveToFront(node, c.lst)
+	return node.Data.val, nil
+}
+
+// Put works like Cache.Put, but the least recently used key is evicted regardless of frequency.
+func (c *lruCache[K, V]) Put(key K, value V) {
+	c.stats.puts.Add(1)
+	if node, ok := c.keys[key]; ok { // if key is present, updates it and makes it the most recently used one
+		oldValue := node.Data.val
+		node.Data.val = value
+		node.Data.frequency++
+		c.lst.MoveToFront(node, c.lst)
+		c.notifyEvict(key, oldValue, EvictReasonReplaced)
+		return
+	}
+	if c.capacity == 0 { // if there is no place at all
+		c.notifyEvict(key, value, EvictReasonRejected)
+		return
+	}
+	if c.lst.Size() == c.capacity { // if cache is filled, evicts the least recently used key
+		victim := c.lst.PopBack().Data
+		delete(c.keys, victim.key)
+		c.notifyEvict(victim.key, victim.val, EvictReasonCapacity)
+	}
+	c.keys[key] = c.lst.PushFront(lruEntry[K, V]{key, value, 1})
+}
+
+// All returns the iterator in the order of use history, the most recently used key is listed first.
+//
+// O(capacity)
+func (c *lruCache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for entry := range c.lst.All() {
+			if !yield(entry.key, entry.val) {
+				return
+			}
+		}
+	}
+}
+
+func (c *lruCache[K, V]) Size() int {
+	return c.lst.Size()
+}
+
+func (c *lruCache[K, V]) GetKeyFrequency(key K) (int, error) {
+	if node, ok := c.keys[key]; ok {
+		return node.Data.frequency, nil
+	}
+	return 0, ErrKeyNotFound
+}
+
+func (c *lruCache[K, V]) Delete(key K) bool {
+	node, ok := c.keys[key]
+	if !ok {
+		return false
+	}
+	c.lst.Remove(node)
+	delete(c.keys, key)
+	c.notifyEvict(key, node.Data.val, EvictReasonDeleted)
+	return true
+}
+
+func (c *lruCache[K, V]) Clear() {
+	old := c.lst
+	c.lst = linkedlist.NewLinkedList[lruEntry[K, V]]()
+	c.keys = make(map[K]*linkedlist.Node[lruEntry[K, V]], c.capacity)
+	for entry := range old.All() {
+		c.notifyEvict(entry.key, entry.val, EvictReasonDeleted)
+	}
+}
+
+func (c *lruCache[K, V]) Stats() Stats {
+	stats := c.stats.snapshot()
+	stats.Size = c.lst.Size()
+	stats.FrequencyHistogram = make(map[int]int)
+	for entry := range c.lst.All() {
+		stats.FrequencyHistogram[entry.frequency]++
+	}
+	stats.FrequencyClasses = len(stats.FrequencyHistogram)
+	return stats
+}
@@ -0,0 +1,119 @@
+package lfu
+
+import (
+	"time"
+)
+
+// Option configures the cache created by NewWithOptions, NewSharded or NewCache
+type Option[K comparable, V any] func(*options[K, V])
+
+// Auxiliary structure that accumulates values of all given options
+type options[K comparable, V any] struct {
+	defaultTTL   time.Duration     // time to live of keys added by Put, zero means that keys never expire
+	hasher       Hasher[K]         // hash of keys, is used by shardedCache and tinyLFUCache
+	onEvict      OnEvictFunc[K, V] // callback, which is called when the value leaves the cache
+	maxFrequency int               // frequency, which can't be exceeded by keys, zero means no limit
+	agingPeriod  int               // count of Get and Put calls between halvings of frequencies, zero means no aging
+	maxCost      int64             // limit of total cost of keys, zero means no limit
+	costOf       CostFunc[K, V]    // function computing cost of the key and value
+	negativeTTL  time.Duration     // time during which errors of loader are cached, is used only by shardedCache
+	codec        Codec             // format of snapshots
+	now          func() time.Time  // source of current time, is replaced in tests
+	policy       Policy            // algorithm of eviction, is used only by NewCache
+}
+
+// Function applies given options to the default configuration and returns it
+func newOptions[K comparable, V any](opts []Option[K, V]) *options[K, V] {
+	o := &options[K, V]{codec: GobCodec, now: time.Now}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithDefaultTTL sets time to live for keys added by Put.
+// Non-positive ttl means that keys never expire, it is default behaviour.
+func WithDefaultTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.defaultTTL = max(ttl, 0)
+	}
+}
+
+// WithHasher sets hash of keys, which is used by shardedCache to choose the shard of key
+// and by tinyLFUCache to estimate frequency of key. By default, maphash of the key with random seed is used.
+func WithHasher[K comparable, V any](hasher Hasher[K]) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.hasher = hasher
+	}
+}
+
+// WithOnEvict sets callback, which is called every time the value leaves the cache:
+// on eviction by capacity, Delete, Clear, expiration and overwriting by Put.
+func WithOnEvict[K comparable, V any](onEvict OnEvictFunc[K, V]) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onEvict = onEvict
+	}
+}
+
+// WithMaxFrequency limits frequency of keys, so hot keys can't pile up frequency forever.
+// Get and Put of the key with maximal frequency only make it the most recently used one among keys with the same frequency.
+// Non-positive maxFrequency means no limit, it is default behaviour.
+func WithMaxFrequency[K comparable, V any](maxFrequency int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.maxFrequency = max(maxFrequency, 0)
+	}
+}
+
+// WithAgingPeriod makes the cache halve frequencies of all keys after every period calls of Get and Put.
+// Non-positive period means no aging, it is default behaviour.
+func WithAgingPeriod[K comparable, V any](period int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.agingPeriod = max(period, 0)
+	}
+}
+
+// WithMaxCost limits total cost of keys in the cache, cost of every key is computed by costOf once per Put.
+// Put evicts the least frequently used keys until the new value fits, values, which cost more than maxCost, are rejected.
+// The limit by count of keys given to the constructor still works. If costOf is nil, every key costs one.
+// Non-positive maxCost means no limit, it is default behaviour.
+func WithMaxCost[K comparable, V any](maxCost int64, costOf CostFunc[K, V]) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.maxCost = max(maxCost, 0)
+		o.costOf = costOf
+		if o.costOf == nil {
+			o.costOf = func(K, V) int64 { return 1 }
+		}
+	}
+}
+
+// WithNegativeTTL makes GetOrLoad remember errors of loader for the given time,
+// so the failing backend isn't called for the key again until the time passes.
+// Non-positive ttl means that errors aren't cached, it is default behaviour.
+func WithNegativeTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.negativeTTL = max(ttl, 0)
+	}
+}
+
+// WithCodec sets the format of snapshots written by Snapshot and read by Restore, GobCodec is used by default.
+func WithCodec[K comparable, V any](codec Codec) Option[K, V] {
+	return func(o *options[K, V]) {
+		if codec != nil {
+			o.codec = codec
+		}
+	}
+}
+
+// WithPolicy sets the algorithm of eviction of the cache created by NewCache, PolicyLFU is used by default.
+func WithPolicy[K comparable, V any](policy Policy) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.policy = policy
+	}
+}
+
+// Option replacing the source of current time, it is needed to test expiration without sleeping
+func withClock[K comparable, V any](now func() time.Time) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.now = now
+	}
+}
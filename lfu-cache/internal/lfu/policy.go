@@ -0,0 +1,127 @@
+package lfu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupportedOption is returned by TryNewCache, when the option isn't supported by the chosen policy
+var ErrUnsupportedOption = errors.New("option isn't supported by the policy")
+
+// Policy selects the algorithm, which chooses the key to evict from the filled cache
+type Policy int
+
+const (
+	// PolicyLFU evicts the least frequently used key, ties are broken by recency. It is the default policy
+	PolicyLFU Policy = iota
+	// PolicyLRU evicts the least recently used key
+	PolicyLRU
+	// PolicyARC is Adaptive Replacement Cache, which balances recency and frequency using history of evicted keys
+	PolicyARC
+	// PolicyLFUDA is LFU with dynamic aging: priority of the key is its frequency plus age of the cache at the last use,
+	// so keys, which were popular long ago, don't stay in the cache forever.
+	// Keys are ordered by priority in the heap, so Get, Put and Delete take O(log n), where n is count of different
+	// priorities, rather than O(1) of other policies
+	PolicyLFUDA
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyLFU:
+		return "lfu"
+	case PolicyLRU:
+		return "lru"
+	case PolicyARC:
+		return "arc"
+	case PolicyLFUDA:
+		return "lfu-da"
+	default:
+		return "unknown"
+	}
+}
+
+// NewCache initializes the cache with the given capacity and the policy chosen by WithPolicy, LFU is used by default.
+// Complexity of methods is documented on Cache, except PolicyLFUDA, which documents its own.
+// If capacity, policy or options are incorrect, NewCache panics, use TryNewCache to get error instead.
+func NewCache[K comparable, V any](capacity int, opts ...Option[K, V]) Cache[K, V] {
+	cache, err := TryNewCache(capacity, opts...)
+	if err != nil { // if arguments are incorrect, NewCache panics
+		panic(err)
+	}
+	return cache
+}
+
+// TryNewCache works like NewCache, but returns error instead of panicking.
+// PolicyLFU supports all options. Other policies support only WithOnEvict, if any other option is given,
+// TryNewCache returns ErrUnsupportedOption rather than creating the cache, which ignores it.
+func TryNewCache[K comparable, V any](capacity int, opts ...Option[K, V]) (Cache[K, V], error) {
+	if capacity < 0 { // if capacity is incorrect, returns error
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCapacity, capacity)
+	}
+	o := newOptions(opts)
+	if names := o.lfuOnly(); o.policy != PolicyLFU && len(names) > 0 { // if the policy would ignore options, returns error
+		return nil, fmt.Errorf("%w: %s doesn't support %s", ErrUnsupportedOption, o.policy, strings.Join(names, ", "))
+	}
+	switch o.policy {
+	case PolicyLFU:
+		return TryNew(capacity, opts...)
+	case PolicyLRU:
+		return newLRU(capacity, o.onEvict), nil
+	case PolicyARC:
+		return newARC(capacity, o.onEvict), nil
+	case PolicyLFUDA:
+		return newLFUDA(capacity, o.onEvict), nil
+	default:
+		return nil, fmt.Errorf("unknown policy %d", o.policy)
+	}
+}
+
+// Function returns names of given options, which are supported only by PolicyLFU
+func (o *options[K, V]) lfuOnly() []string {
+	var names []string
+	if o.defaultTTL > 0 {
+		names = append(names, "WithDefaultTTL")
+	}
+	if o.maxFrequency > 0 {
+		names = append(names, "WithMaxFrequency")
+	}
+	if o.agingPeriod > 0 {
+		names = append(names, "WithAgingPeriod")
+	}
+	if o.costOf != nil {
+		names = append(names, "WithMaxCost")
+	}
+	if _, ok := o.codec.(gobCodec); !ok {
+		names = append(names, "WithCodec")
+	}
+	return names
+}
+
+// Auxiliary structure with the state, which is common for all policies except PolicyLFU
+type policyBase[K comparable, V any] struct {
+	capacity int
+	onEvict  OnEvictFunc[K, V] // callback, which is called when the value leaves the cache, may be nil
+	stats    statsCounters     // counters of calls and evictions
+}
+
+// Function counts removing of the value and calls onEvict callback, if it is set
+func (b *policyBase[K, V]) notifyEvict(key K, value V, reason EvictReason) {
+	b.stats.countEviction(reason)
+	if b.onEvict != nil {
+		b.onEvict(key, value, reason)
+	}
+}
+
+// Function counts Get call
+func (b *policyBase[K, V]) countGet(found bool) {
+	if found {
+		b.stats.hits.Add(1)
+	} else {
+		b.stats.misses.Add(1)
+	}
+}
+
+func (b *policyBase[K, V]) Capacity() int {
+	return b.capacity
+}
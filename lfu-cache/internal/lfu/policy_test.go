@@ -0,0 +1,394 @@
+package lfu
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var policies = []Policy{PolicyLFU, PolicyLRU, PolicyARC, PolicyLFUDA}
+
+// TestPolicyConformance runs checks, which every policy must pass, they are derived from lfu_test.go
+func TestPolicyConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range policies {
+		t.Run(policy.String(), func(t *testing.T) {
+			t.Parallel()
+
+			newCache := func(capacity int, opts ...Option[int, int]) Cache[int, int] {
+				return NewCache(capacity, append(opts, WithPolicy[int, int](policy))...)
+			}
+
+			t.Run("KeyNotFound", func(t *testing.T) {
+				cache := newCache(3)
+
+				_, err := cache.Get(1)
+				require.ErrorIs(t, err, ErrKeyNotFound)
+				_, err = cache.GetKeyFrequency(1)
+				require.ErrorIs(t, err, ErrKeyNotFound)
+			})
+
+			t.Run("GetPut", func(t *testing.T) {
+				cache := newCache(3)
+				require.Equal(t, 3, cache.Capacity())
+
+				cache.Put(1, 1)
+				cache.Put(2, 4)
+				cache.Put(3, 9)
+				require.Equal(t, 3, cache.Size())
+
+				for k := 1; k <= 3; k++ {
+					value, err := cache.Get(k)
+					require.NoError(t, err)
+					require.Equal(t, k*k, value)
+				}
+			})
+
+			t.Run("UpdateValueChangeFrequency", func(t *testing.T) {
+				cache := newCache(2)
+
+				cache.Put(1, 1)
+				_, _ = cache.Get(1)
+				cache.Put(1, 10)
+
+				value, err := cache.Get(1)
+				require.NoError(t, err)
+				require.Equal(t, 10, value)
+
+				freq, err := cache.GetKeyFrequency(1)
+				require.NoError(t, err)
+				require.Equal(t, 4, freq)
+				require.Equal(t, 1, cache.Size())
+			})
+
+			t.Run("EvictionTieBreaker", func(t *testing.T) {
+				cache := newCache(2)
+
+				cache.Put(1, 1)
+				cache.Put(2, 2)
+				cache.Put(3, 3)
+
+				_, err := cache.Get(1)
+				require.ErrorIs(t, err, ErrKeyNotFound)
+				keys, _ := collect(cache.All())
+				require.ElementsMatch(t, []int{2, 3}, keys)
+			})
+
+			t.Run("IteratorStops", func(t *testing.T) {
+				cache := newCache(5)
+				for i := range 5 {
+					cache.Put(i, i)
+				}
+
+				count := 0
+				for range cache.All() {
+					count++
+					if count == 2 {
+						break
+					}
+				}
+				require.Equal(t, 2, count)
+			})
+
+			t.Run("IteratorsDontChangeFrequency", func(t *testing.T) {
+				cache := newCache(5)
+				for i := range 5 {
+					for range i + 1 {
+						cache.Put(i, i*10)
+					}
+				}
+				before := slices.Collect(cache.AllWithFrequency())
+				for _, entry := range before {
+					freq, err := cache.GetKeyFrequency(entry.Key)
+					require.NoError(t, err)
+					require.Equal(t, freq, entry.Frequency)
+				}
+
+				keys, values := collect(cache.All())
+				require.Equal(t, keys, slices.Collect(cache.Keys()))
+				require.Equal(t, values, slices.Collect(cache.Values()))
+
+				coldest := slices.Collect(cache.Coldest(10))
+				slices.Reverse(coldest)
+				require.Equal(t, before, coldest)
+				require.Len(t, slices.Collect(cache.Coldest(2)), 2)
+				require.Empty(t, slices.Collect(cache.Coldest(0)))
+
+				require.Equal(t, before, slices.Collect(cache.AllWithFrequency()))
+			})
+
+			t.Run("NonPromotingReads", func(t *testing.T) {
+				cache := newCache(2)
+
+				cache.Put(1, 10)
+				cache.Put(2, 20)
+				_, _ = cache.Get(2)
+				for range 5 {
+					value, err := cache.Peek(1)
+					require.NoError(t, err)
+					require.Equal(t, 10, value)
+					require.True(t, cache.Contains(1))
+				}
+				_, err := cache.Peek(3)
+				require.ErrorIs(t, err, ErrKeyNotFound)
+				require.False(t, cache.Contains(3))
+
+				freq, err := cache.GetKeyFrequency(1)
+				require.NoError(t, err)
+				require.Equal(t, 1, freq)
+				require.Zero(t, cache.Stats().Misses)
+
+				cache.Put(3, 30) // 1 is still the coldest key
+				require.False(t, cache.Contains(1))
+			})
+
+			t.Run("ConditionalWrites", func(t *testing.T) {
+				cache := newCache(2)
+
+				require.True(t, cache.PutIfAbsent(1, 10))
+				require.False(t, cache.PutIfAbsent(1, 11))
+				value, err := cache.Peek(1)
+				require.NoError(t, err)
+				require.Equal(t, 10, value)
+
+				require.False(t, CompareAndSwap(cache, 1, 11, 12))
+				require.False(t, CompareAndSwap(cache, 2, 0, 12))
+				require.False(t, cache.Contains(2))
+				require.True(t, CompareAndSwap(cache, 1, 10, 12))
+				value, err = cache.Peek(1)
+				require.NoError(t, err)
+				require.Equal(t, 12, value)
+			})
+
+			t.Run("DeleteAndClear", func(t *testing.T) {
+				recorder := new(evictRecorder)
+				cache := newCache(3, WithOnEvict(recorder.onEvict))
+
+				cache.Put(1, 10)
+				cache.Put(2, 20)
+				require.True(t, cache.Delete(1))
+				require.False(t, cache.Delete(1))
+				require.Equal(t, 1, cache.Size())
+
+				cache.Clear()
+				require.Equal(t, 0, cache.Size())
+				keys, _ := collect(cache.All())
+				require.Empty(t, keys)
+				require.Equal(t, []evictEvent{
+					{1, 10, EvictReasonDeleted},
+					{2, 20, EvictReasonDeleted},
+				}, recorder.events)
+
+				cache.Put(3, 30)
+				value, err := cache.Get(3)
+				require.NoError(t, err)
+				require.Equal(t, 30, value)
+			})
+
+			t.Run("ZeroCapacity", func(t *testing.T) {
+				recorder := new(evictRecorder)
+				cache := newCache(0, WithOnEvict(recorder.onEvict))
+
+				cache.Put(1, 10)
+				require.Equal(t, 0, cache.Size())
+				require.Equal(t, []evictEvent{{1, 10, EvictReasonRejected}}, recorder.events)
+			})
+
+			t.Run("RandomWorkload", func(t *testing.T) {
+				const capacity = 50
+				present := make(map[int]int)
+				cache := newCache(capacity, WithOnEvict(func(key int, _ int, reason EvictReason) {
+					if reason != EvictReasonReplaced {
+						delete(present, key)
+					}
+				}))
+				r := rand.New(rand.NewPCG(1, 2))
+
+				for range 10_000 {
+					key := r.IntN(200)
+					switch r.IntN(10) {
+					case 0:
+						require.Equal(t, present[key] != 0, cache.Delete(key))
+					case 1, 2, 3, 4:
+						value, err := cache.Get(key)
+						if want, ok := present[key]; ok {
+							require.NoError(t, err)
+							require.Equal(t, want, value)
+						} else {
+							require.ErrorIs(t, err, ErrKeyNotFound)
+						}
+					default:
+						cache.Put(key, key+1)
+						present[key] = key + 1
+					}
+					require.LessOrEqual(t, cache.Size(), capacity)
+					require.Equal(t, len(present), cache.Size())
+				}
+
+				keys, values := collect(cache.All())
+				require.Len(t, keys, len(present))
+				for i, k := range keys {
+					require.Equal(t, present[k], values[i])
+				}
+
+				stats := cache.Stats()
+				require.Equal(t, cache.Size(), stats.Size)
+				sum := 0
+				for _, count := range stats.FrequencyHistogram {
+					sum += count
+				}
+				require.Equal(t, stats.Size, sum)
+				require.NotZero(t, stats.Hits)
+				require.NotZero(t, stats.Misses)
+				require.NotZero(t, stats.Evictions)
+			})
+		})
+	}
+}
+
+func TestTryNewCache(t *testing.T) {
+	t.Parallel()
+
+	_, err := TryNewCache[int, int](-1, WithPolicy[int, int](PolicyLRU))
+	require.ErrorIs(t, err, ErrInvalidCapacity)
+	_, err = TryNewCache[int, int](1, WithPolicy[int, int](Policy(42)))
+	require.Error(t, err)
+
+	cache, err := TryNewCache[int, int](1)
+	require.NoError(t, err)
+	require.IsType(t, (*cacheImpl[int, int])(nil), cache)
+
+	require.Equal(t, "lfu-da", PolicyLFUDA.String())
+	require.Equal(t, "unknown", Policy(42).String())
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	cache := NewCache(3, WithPolicy[int, int](PolicyLRU))
+
+	cache.Put(1, 1)
+	for range 10 {
+		_, _ = cache.Get(1)
+	}
+	cache.Put(2, 2)
+	cache.Put(3, 3)
+	_, _ = cache.Get(2)
+	cache.Put(4, 4) // 1 is the most frequently used key, but it is the least recently used one
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{4, 2, 3}, keys)
+}
+
+func TestARCAdaptsToFrequency(t *testing.T) {
+	t.Parallel()
+
+	cache := NewCache(4, WithPolicy[int, int](PolicyARC))
+	arc := cache.(*arcCache[int, int])
+
+	cache.Put(1, 1)
+	cache.Put(2, 2)
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(2) // 1 and 2 are frequent now
+
+	for k := 100; k < 110; k++ { // scan of keys used once doesn't wash out frequent keys
+		cache.Put(k, k)
+	}
+	for _, k := range []int{1, 2} {
+		_, err := cache.Get(k)
+		require.NoError(t, err)
+	}
+
+	cache.Put(107, 107) // key from B1 makes T1 target grow
+	require.Equal(t, 1, arc.target)
+	_, err := cache.Get(107)
+	require.NoError(t, err)
+	require.Equal(t, arcFrequent, arc.keys[107].Data.list)
+	require.Equal(t, 4, cache.Size())
+	require.LessOrEqual(t, len(arc.keys), 8)
+}
+
+func TestLFUDAEvictsStaleKeys(t *testing.T) {
+	t.Parallel()
+
+	lfuCache := NewCache(2, WithPolicy[int, int](PolicyLFU))
+	lfudaCache := NewCache(2, WithPolicy[int, int](PolicyLFUDA))
+
+	for _, cache := range []Cache[int, int]{lfuCache, lfudaCache} {
+		cache.Put(1, 1)
+		for range 3 {
+			_, _ = cache.Get(1) // 1 was popular long ago
+		}
+		for k := 2; k < 10; k++ { // then every new key is used twice
+			cache.Put(k, k)
+			_, _ = cache.Get(k)
+		}
+	}
+
+	_, err := lfuCache.Get(1)
+	require.NoError(t, err)
+	_, err = lfudaCache.Get(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	keys, _ := collect(lfudaCache.All())
+	require.Equal(t, []int{9, 8}, keys)
+}
+
+func TestLFUDAManyPriorities(t *testing.T) {
+	t.Parallel()
+
+	const capacity = 50
+	cache := newLFUDA[int, int](capacity, nil)
+	r := rand.New(rand.NewPCG(1, 1))
+	for _, k := range r.Perm(capacity) { // every key gets its own priority, classes are created in random order
+		cache.Put(k, k)
+		for range k {
+			_, _ = cache.Get(k)
+		}
+	}
+	for _, k := range r.Perm(capacity)[:10] { // some classes are removed from the middle of the heap
+		_, _ = cache.Get(k)
+	}
+
+	var previous int
+	for entry := range cache.Coldest(capacity) {
+		require.GreaterOrEqual(t, cache.keys[entry.Key].Data.class.priority, previous)
+		previous = cache.keys[entry.Key].Data.class.priority
+	}
+	victim := cache.heap[0].lst.Back().Data.key
+	for entry := range cache.Coldest(1) {
+		require.Equal(t, victim, entry.Key)
+	}
+
+	cache.Put(100, 100)
+	require.False(t, cache.Contains(victim))
+	require.Equal(t, previous, cache.sortedClasses()[0].priority)
+	for i, class := range cache.heap {
+		require.Equal(t, i, class.index)
+		require.Same(t, class, cache.classes[class.priority])
+		if i > 0 {
+			require.LessOrEqual(t, cache.heap[(i-1)/2].priority, class.priority)
+		}
+	}
+}
+
+func TestTryNewCacheUnsupportedOptions(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range policies[1:] {
+		_, err := TryNewCache(2, WithPolicy[int, int](policy), WithDefaultTTL[int, int](time.Minute), WithMaxCost[int, int](10, nil))
+		require.ErrorIs(t, err, ErrUnsupportedOption)
+		require.ErrorContains(t, err, policy.String()+" doesn't support WithDefaultTTL, WithMaxCost")
+
+		_, err = TryNewCache(2, WithPolicy[int, int](policy), WithOnEvict[int, int](func(int, int, EvictReason) {}))
+		require.NoError(t, err)
+	}
+
+	cache, err := TryNewCache(2, WithDefaultTTL[int, int](time.Minute), WithMaxCost[int, int](10, nil))
+	require.NoError(t, err)
+	require.Equal(t, 2, cache.Capacity())
+}
@@ -0,0 +1,68 @@
+package lfu
+
+import (
+	"lfucache/pkg/linkedlist"
+)
+
+// Nodes of removed keys and empty frequency classes aren't dropped, they are moved to free lists of cacheImpl
+// and are reused by the next Put or increasing of frequency. Moving nodes between lists doesn't allocate memory,
+// so the filled cache allocates nothing on Put and Get. Free lists can't be longer than capacity,
+// because every node is taken from them before a new one is allocated.
+
+// Function puts data to the front of lst, reusing free node, if there is one
+func (l *cacheImpl[K, V]) pushKeyFront(lst linkedlist.LinkedList[valOfKey[K, V]], data valOfKey[K, V]) *linkedlist.Node[valOfKey[K, V]] {
+	node := l.freeNodes.Front()
+	if node == nil { // if there is no free node, allocates a new one
+		return lst.PushFront(data)
+	}
+	lst.MoveToFront(node, l.freeNodes)
+	node.Data = data
+	return node
+}
+
+// Function puts data to the back of lst, reusing free node, if there is one
+func (l *cacheImpl[K, V]) pushKeyBack(lst linkedlist.LinkedList[valOfKey[K, V]], data valOfKey[K, V]) *linkedlist.Node[valOfKey[K, V]] {
+	node := l.freeNodes.Front()
+	if node == nil { // if there is no free node, allocates a new one
+		return lst.PushBack(data)
+	}
+	lst.MoveToBack(node, l.freeNodes)
+	node.Data = data
+	return node
+}
+
+// Function moves node of removed key from lst to free list. Data of node is zeroed, so that key and value can be collected
+func (l *cacheImpl[K, V]) releaseKey(node *linkedlist.Node[valOfKey[K, V]], lst linkedlist.LinkedList[valOfKey[K, V]]) {
+	l.freeNodes.MoveToFront(node, lst)
+	node.Data = valOfKey[K, V]{}
+}
+
+// Function creates class with the given frequency before the given node of frequencyClasses or at the back, if node is nil.
+// Free class is reused, if there is one
+func (l *cacheImpl[K, V]) pushClass(frequency int, before *linkedlist.Node[*classFrequency[K, V]]) *linkedlist.Node[*classFrequency[K, V]] {
+	classes := l.frequencyClasses
+	node := l.freeClasses.Front()
+	switch {
+	case node == nil && before == nil: // if there is no free class, allocates a new one
+		return classes.PushBack(newClassFrequency[K, V](frequency))
+	case node == nil:
+		return classes.PushBefore(before, newClassFrequency[K, V](frequency))
+	case before == nil:
+		classes.MoveToBack(node, l.freeClasses)
+	default:
+		classes.MoveBefore(node, before, l.freeClasses)
+	}
+	node.Data.frequency = frequency
+	return node
+}
+
+// Function moves empty class from frequencyClasses to free list
+func (l *cacheImpl[K, V]) releaseClass(node *linkedlist.Node[*classFrequency[K, V]]) {
+	l.freeClasses.MoveToFront(node, l.frequencyClasses)
+}
+
+// Function drops free lists, so that their memory can be collected, it is used when the cache shrinks
+func (l *cacheImpl[K, V]) dropFree() {
+	l.freeNodes = linkedlist.NewLinkedList[valOfKey[K, V]]()
+	l.freeClasses = linkedlist.NewLinkedList[*classFrequency[K, V]]()
+}
@@ -0,0 +1,118 @@
+package lfu
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilledCacheDoesNotAllocate(t *testing.T) {
+	cache := New[int, int](100)
+	for i := range 100 {
+		cache.Put(i, i)
+	}
+
+	key := 100
+	allocs := testing.AllocsPerRun(1_000, func() {
+		cache.Put(key, key) // evicts the coldest key
+		_, _ = cache.Get(key)
+		_, _ = cache.Get(key - 1)
+		cache.Delete(key - 50)
+		key++
+	})
+	require.Zero(t, allocs)
+}
+
+func TestFreeListsAreBounded(t *testing.T) {
+	t.Parallel()
+
+	const capacity = 50
+	cache := New[int, int](capacity)
+	r := rand.New(rand.NewPCG(3, 4))
+
+	for range 10_000 {
+		key := r.IntN(200)
+		switch r.IntN(4) {
+		case 0:
+			cache.Delete(key)
+		case 1:
+			_, _ = cache.Get(key)
+		default:
+			cache.Put(key, key)
+		}
+		require.LessOrEqual(t, cache.Size()+cache.freeNodes.Size(), capacity)
+		require.LessOrEqual(t, cache.frequencyClasses.Size()+cache.freeClasses.Size(), capacity+1)
+	}
+
+	require.NoError(t, cache.Resize(10))
+	require.Zero(t, cache.freeNodes.Size())
+	cache.Clear()
+	require.Zero(t, cache.freeNodes.Size())
+	require.Zero(t, cache.freeClasses.Size())
+}
+
+func TestFreeNodesDropValues(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, *int](2)
+	cache.Put(1, new(int))
+	cache.Put(2, new(int))
+	cache.Put(3, new(int)) // evicts 1 and reuses its node at once
+	cache.Delete(2)
+	cache.Delete(3)
+
+	require.Equal(t, 2, cache.freeNodes.Size())
+	for data := range cache.freeNodes.All() {
+		require.Nil(t, data.val)
+		require.Nil(t, data.nodeFreqClass)
+	}
+
+	cache.Put(4, new(int)) // reuses free node
+	require.Equal(t, 1, cache.freeNodes.Size())
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{4}, keys)
+}
+
+// Benchmarks of allocations made by cacheImpl, run them with -benchmem to compare pooling with plain allocation
+
+func BenchmarkPutWithEviction(b *testing.B) {
+	cache := New[int, int](1_000)
+	for i := range 1_000 {
+		cache.Put(i, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := range b.N {
+		cache.Put(i+1_000, i)
+	}
+}
+
+func BenchmarkPutGet(b *testing.B) {
+	cache := New[int, int](100)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := range b.N {
+		cache.Put(i, i)
+		_, _ = cache.Get(i - 1)
+	}
+}
+
+func BenchmarkDeletePut(b *testing.B) {
+	cache := New[int, int](1_000)
+	for i := range 1_000 {
+		cache.Put(i, i)
+		_, _ = cache.Get(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := range b.N {
+		key := i % 1_000
+		cache.Delete(key)
+		cache.Put(key, i)
+		_, _ = cache.Get(key)
+	}
+}
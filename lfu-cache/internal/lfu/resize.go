@@ -0,0 +1,46 @@
+package lfu
+
+import (
+	"fmt"
+)
+
+// Resize changes capacity of the cache. If the new capacity is less than the size, the least frequently used keys
+// are evicted in the same order as Put evicts them, onEvict callback is called for them with EvictReasonCapacity.
+// Growing doesn't reallocate anything. If capacity is negative, Resize returns ErrInvalidCapacity and doesn't change the cache.
+//
+// O(1) for growing, O(count of evicted keys) for shrinking
+func (l *cacheImpl[K, V]) Resize(capacity int) error {
+	if capacity < 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidCapacity, capacity)
+	}
+	for l.size > capacity { // evicts keys from the least frequency class from the least recently used one
+		l.removeNode(l.victimNode(nil), EvictReasonCapacity)
+	}
+	if capacity < l.capacity { // free nodes of evicted keys would exceed the new capacity
+		l.dropFree()
+	}
+	l.capacity = capacity
+	return nil
+}
+
+// Resize splits the new capacity between shards like NewSharded and resizes every shard, see cacheImpl.Resize.
+// Shards are resized one by one, so Capacity changes only after all shards have been resized.
+// If capacity is less than count of shards, Resize returns ErrInvalidCapacity and doesn't change the cache.
+func (c *shardedCache[K, V]) Resize(capacity int) error {
+	if err := checkShards(capacity, len(c.shards)); err != nil {
+		return err
+	}
+	c.resizeMu.Lock()
+	defer c.resizeMu.Unlock()
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		err := s.cache.Resize(int(splitEvenly(int64(capacity), len(c.shards), i)))
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	c.capacity.Store(int64(capacity))
+	return nil
+}
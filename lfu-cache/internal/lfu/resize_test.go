@@ -0,0 +1,125 @@
+package lfu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResizeShrinkEvictsInOrder(t *testing.T) {
+	t.Parallel()
+
+	recorder := new(evictRecorder)
+	cache := NewWithOptions(5, WithOnEvict(recorder.onEvict))
+
+	for i := range 5 {
+		cache.Put(i, i*10)
+	}
+	_, _ = cache.Get(0)
+	_, _ = cache.Get(1)
+
+	require.NoError(t, cache.Resize(2))
+	require.Equal(t, 2, cache.Capacity())
+	require.Equal(t, 2, cache.Size())
+	require.Equal(t, []evictEvent{
+		{2, 20, EvictReasonCapacity},
+		{3, 30, EvictReasonCapacity},
+		{4, 40, EvictReasonCapacity},
+	}, recorder.events)
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{1, 0}, keys)
+}
+
+func TestResizeGrow(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, int](2)
+	cache.Put(1, 10)
+	cache.Put(2, 20)
+
+	require.NoError(t, cache.Resize(4))
+	require.Equal(t, 4, cache.Capacity())
+
+	cache.Put(3, 30)
+	cache.Put(4, 40)
+	require.Equal(t, 4, cache.Size())
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{4, 3, 2, 1}, keys)
+}
+
+func TestResizeToZero(t *testing.T) {
+	t.Parallel()
+
+	recorder := new(evictRecorder)
+	cache := NewWithOptions(2, WithOnEvict(recorder.onEvict))
+	cache.Put(1, 10)
+
+	require.NoError(t, cache.Resize(0))
+	require.Equal(t, 0, cache.Size())
+
+	cache.Put(2, 20)
+	require.Equal(t, 0, cache.Size())
+	require.Equal(t, []evictEvent{
+		{1, 10, EvictReasonCapacity},
+		{2, 20, EvictReasonRejected},
+	}, recorder.events)
+}
+
+func TestResizeNegative(t *testing.T) {
+	t.Parallel()
+
+	cache := New[int, int](2)
+	cache.Put(1, 10)
+
+	require.ErrorIs(t, cache.Resize(-1), ErrInvalidCapacity)
+	require.Equal(t, 2, cache.Capacity())
+	require.Equal(t, 1, cache.Size())
+}
+
+func TestTryNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := TryNew[int, int](-1)
+	require.ErrorIs(t, err, ErrInvalidCapacity)
+
+	cache, err := TryNew[int, int](3)
+	require.NoError(t, err)
+	require.Equal(t, 3, cache.Capacity())
+
+	_, err = TryNewSharded[int, int](2, 4)
+	require.ErrorIs(t, err, ErrInvalidCapacity)
+	_, err = TryNewSharded[int, int](8, 0)
+	require.ErrorIs(t, err, ErrInvalidCapacity)
+	_, err = TryNewSharded(8, 4, WithMaxCost[int, int](2, nil))
+	require.ErrorIs(t, err, ErrInvalidCapacity)
+
+	require.Panics(t, func() { NewSharded[int, int](2, 4) })
+}
+
+func TestShardedResize(t *testing.T) {
+	t.Parallel()
+
+	evicted := 0
+	cache := NewSharded(8, 4,
+		WithOnEvict(func(int, int, EvictReason) { evicted++ }),
+		identityHasher(),
+	)
+	for i := range 8 {
+		cache.Put(i, i)
+	}
+
+	require.NoError(t, cache.Resize(4))
+	require.Equal(t, 4, cache.Capacity())
+	require.Equal(t, 4, cache.Size())
+	require.Equal(t, 4, evicted)
+
+	require.ErrorIs(t, cache.Resize(3), ErrInvalidCapacity)
+	require.Equal(t, 4, cache.Capacity())
+
+	require.NoError(t, cache.Resize(16))
+	for i := range 16 {
+		cache.Put(i, i)
+	}
+	require.Equal(t, 16, cache.Size())
+}
@@ -0,0 +1,316 @@
+package lfu
+
+import (
+	"fmt"
+	"hash/maphash"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Hasher maps key to the number, which is used to choose the shard of shardedCache
+type Hasher[K comparable] func(key K) uint64
+
+// shardedCache represents thread-safe LFU cache, which partitions keys across independently locked shards.
+// Every shard is cacheImpl with its own part of capacity, so eviction happens inside the shard of the key
+type shardedCache[K comparable, V any] struct {
+	shards   []shard[K, V]    // shards of cache, key is always stored in shards[hash(key) % len(shards)]
+	hash     Hasher[K]        // function choosing shard of key
+	capacity atomic.Int64     // total capacity of all shards, is changed by Resize
+	resizeMu sync.Mutex       // serializes calls of Resize
+	maxCost  int64            // total limit of cost of all shards, zero means no limit
+	negTTL   time.Duration    // time during which errors of loader are cached, zero means that errors aren't cached
+	now      func() time.Time // source of current time for checking expiration of cached errors
+	codec    Codec            // format of snapshots
+}
+
+// Auxiliary structure that binds cacheImpl with mutex guarding it
+type shard[K comparable, V any] struct {
+	mu       sync.Mutex
+	cache    *cacheImpl[K, V]
+	inflight map[K]*loadCall[V] // loads of keys, which are running now, is created lazily
+	failures map[K]loadFailure  // cached errors of loader, is created lazily
+	_        [32]byte           // padding, so that neighbouring shards don't share the cache line
+}
+
+// NewSharded initializes the thread-safe cache with the given total capacity split between the given count of shards.
+// Given options are applied to every shard, the limit of cost is split between shards like capacity.
+// If no hasher is provided, the cache will use maphash of the key with random seed.
+// NewSharded panics if count of shards is not positive or is greater than capacity or limit of cost.
+func NewSharded[K comparable, V any](capacity, shards int, opts ...Option[K, V]) *shardedCache[K, V] {
+	c, err := TryNewSharded(capacity, shards, opts...)
+	if err != nil { // if every shard can't get at least one place, NewSharded panics
+		panic(err)
+	}
+	return c
+}
+
+// TryNewSharded works like NewSharded, but returns ErrInvalidCapacity instead of panicking.
+func TryNewSharded[K comparable, V any](capacity, shards int, opts ...Option[K, V]) (*shardedCache[K, V], error) {
+	if err := checkShards(capacity, shards); err != nil {
+		return nil, err
+	}
+	o := newOptions(opts)
+	if o.maxCost > 0 && o.maxCost < int64(shards) { // if limit of cost would be zero in some shards, it would mean no limit
+		return nil, fmt.Errorf("%w: limit of cost %d is less than count of shards %d", ErrInvalidCapacity, o.maxCost, shards)
+	}
+	hash := o.hasher
+	if hash == nil { // if hasher wasn't given
+		hash = defaultHasher[K]()
+	}
+
+	c := &shardedCache[K, V]{
+		shards:  make([]shard[K, V], shards),
+		hash:    hash,
+		maxCost: o.maxCost,
+		negTTL:  o.negativeTTL,
+		now:     o.now,
+		codec:   o.codec,
+	}
+	for i := range c.shards {
+		shardOpts := opts
+		if o.maxCost > 0 { // overrides the limit of cost by the part of shard
+			shardOpts = append(opts[:len(opts):len(opts)], WithMaxCost(splitEvenly(o.maxCost, shards, i), o.costOf))
+		}
+		c.shards[i].cache = NewWithOptions[K, V](int(splitEvenly(int64(capacity), shards, i)), shardOpts...)
+	}
+	c.capacity.Store(int64(capacity))
+	return c, nil
+}
+
+// Function returns part of total given to i-th of n shards, the remainder is distributed between the first shards
+func splitEvenly(total int64, n, i int) int64 {
+	part := total / int64(n)
+	if int64(i) < total%int64(n) {
+		part++
+	}
+	return part
+}
+
+// Function returns Hasher based on maphash with random seed
+func defaultHasher[K comparable]() Hasher[K] {
+	seed := maphash.MakeSeed()
+	return func(key K) uint64 {
+		return maphash.Comparable(seed, key)
+	}
+}
+
+// Function returns shard which is responsible for the given key
+func (c *shardedCache[K, V]) shardOf(key K) *shard[K, V] {
+	return &c.shards[c.shardIndex(key)]
+}
+
+// Function returns index of shard which is responsible for the given key
+func (c *shardedCache[K, V]) shardIndex(key K) int {
+	return int(c.hash(key) % uint64(len(c.shards)))
+}
+
+// Function checks that every of shards can get at least one place of capacity
+func checkShards(capacity, shards int) error {
+	if shards <= 0 || shards > capacity {
+		return fmt.Errorf("%w: capacity %d can't be split between %d shards", ErrInvalidCapacity, capacity, shards)
+	}
+	return nil
+}
+
+func (c *shardedCache[K, V]) Get(key K) (V, error) {
+	s := c.shardOf(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Get(key)
+}
+
+func (c *shardedCache[K, V]) Put(key K, value V) {
+	s := c.shardOf(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forgetKey(key)
+	s.cache.Put(key, value)
+}
+
+// PutWithTTL works like Put, but key expires after the given ttl instead of the default one.
+// Non-positive ttl means that key never expires.
+func (c *shardedCache[K, V]) PutWithTTL(key K, value V, ttl time.Duration) {
+	s := c.shardOf(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forgetKey(key)
+	s.cache.PutWithTTL(key, value, ttl)
+}
+
+// All takes the snapshot of every shard and merges them in descending order of frequency.
+// Keys with the same frequency from one shard keep their order, keys with the same frequency
+// from different shards are listed in order of shards.
+//
+// O(capacity * shards)
+func (c *shardedCache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for entry := range c.allWithFrequency() {
+			if !yield(entry.key, entry.val) { // checks that user wants next value
+				return
+			}
+		}
+	}
+}
+
+// Function returns iterator over alive keys of all shards with their frequencies in the same order as All
+func (c *shardedCache[K, V]) allWithFrequency() iter.Seq[frequencyEntry[K, V]] {
+	return func(yield func(frequencyEntry[K, V]) bool) {
+		snapshots := make([][]frequencyEntry[K, V], len(c.shards))
+		for i := range c.shards { // takes snapshots, so that yield can be called without holding locks
+			snapshots[i] = c.shards[i].snapshot()
+		}
+
+		for {
+			best := -1
+			for i, snap := range snapshots { // searches shard whose next key has the biggest frequency
+				if len(snap) > 0 && (best == -1 || snap[0].frequency > snapshots[best][0].frequency) {
+					best = i
+				}
+			}
+			if best == -1 { // if all snapshots are over
+				return
+			}
+			el := snapshots[best][0]
+			snapshots[best] = snapshots[best][1:]
+			if !yield(el) { // checks that user wants next value
+				return
+			}
+		}
+	}
+}
+
+// Function copies keys, values and frequencies of shard in order of iterating over its cache
+func (s *shard[K, V]) snapshot() []frequencyEntry[K, V] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]frequencyEntry[K, V], 0, s.cache.Size())
+	for el := range s.cache.allWithFrequency() {
+		entries = append(entries, el)
+	}
+	return entries
+}
+
+// Size returns the sum of shards sizes.
+//
+// O(shards)
+func (c *shardedCache[K, V]) Size() int {
+	size := 0
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		size += s.cache.Size()
+		s.mu.Unlock()
+	}
+	return size
+}
+
+func (c *shardedCache[K, V]) Capacity() int {
+	return int(c.capacity.Load())
+}
+
+func (c *shardedCache[K, V]) GetKeyFrequency(key K) (int, error) {
+	s := c.shardOf(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.GetKeyFrequency(key)
+}
+
+// DeleteExpired removes all expired keys from every shard and returns their count, expired cached errors of loader are removed too.
+// Shards are locked one by one, so the cache stays available for keys of other shards.
+//
+// O(capacity)
+func (c *shardedCache[K, V]) DeleteExpired() int {
+	removed := 0
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		removed += s.cache.DeleteExpired()
+		s.deleteExpiredFailures(c.now().UnixNano())
+		s.mu.Unlock()
+	}
+	return removed
+}
+
+// StartJanitor runs the background goroutine, which calls DeleteExpired every interval.
+// Returned function stops the goroutine and waits for its completion, it is safe to call it several times.
+// StartJanitor panics if interval is not positive.
+func (c *shardedCache[K, V]) StartJanitor(interval time.Duration) (stop func()) {
+	if interval <= 0 { // if interval is incorrect, StartJanitor panics
+		panic("The interval of janitor must be greater than zero")
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.DeleteExpired()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-finished
+	}
+}
+
+func (c *shardedCache[K, V]) Delete(key K) bool {
+	s := c.shardOf(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forgetKey(key)
+	return s.cache.Delete(key)
+}
+
+// Clear clears shards one by one, so keys put during Clear in already cleared shards stay in the cache.
+func (c *shardedCache[K, V]) Clear() {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.failures = nil
+		s.forgetLoads()
+		s.cache.Clear()
+		s.mu.Unlock()
+	}
+}
+
+// Age halves frequencies of all keys in every shard, see cacheImpl.Age.
+//
+// O(capacity)
+func (c *shardedCache[K, V]) Age() {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.cache.Age()
+		s.mu.Unlock()
+	}
+}
+
+// Cost returns total cost of keys in all shards.
+//
+// O(shards)
+func (c *shardedCache[K, V]) Cost() int64 {
+	var cost int64
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		cost += s.cache.Cost()
+		s.mu.Unlock()
+	}
+	return cost
+}
+
+// MaxCost returns total limit of cost of all shards or zero, if the cost isn't limited.
+func (c *shardedCache[K, V]) MaxCost() int64 {
+	return c.maxCost
+}
@@ -0,0 +1,205 @@
+package lfu
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// must compile
+var _ Cache[int, int] = (*shardedCache[int, int])(nil)
+
+// single-mutex baseline for benchmarks
+type mutexCache[K comparable, V any] struct {
+	mu    sync.Mutex
+	cache *cacheImpl[K, V]
+}
+
+func (c *mutexCache[K, V]) Get(key K) (V, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Get(key)
+}
+
+func (c *mutexCache[K, V]) Put(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache.Put(key, value)
+}
+
+func TestShardedSingleShardBehavesLikeCache(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](3, 1)
+
+	cache.Put(1, 1)
+	cache.Put(2, 4)
+	cache.Put(3, 9)
+
+	_, err := cache.Get(1)
+	require.NoError(t, err)
+	_, err = cache.Get(1)
+	require.NoError(t, err)
+	_, err = cache.Get(3)
+	require.NoError(t, err)
+
+	cache.Put(4, 16)
+
+	_, err = cache.Get(2)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	frequency, err := cache.GetKeyFrequency(1)
+	require.NoError(t, err)
+	require.Equal(t, 3, frequency)
+
+	keys, values := collect(cache.All())
+	require.Equal(t, []int{1, 3, 4}, keys)
+	require.Equal(t, []int{1, 9, 16}, values)
+	require.Equal(t, 3, cache.Size())
+	require.Equal(t, 3, cache.Capacity())
+}
+
+func TestShardedCustomHasher(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](4, 2, WithHasher[int, int](func(key int) uint64 { return uint64(key) }))
+
+	for i := range 4 {
+		cache.Put(i, i)
+	}
+	cache.Put(4, 4) // evicts 0 from shard with even keys
+
+	_, err := cache.Get(0)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	for i := 1; i <= 4; i++ {
+		v, err := cache.Get(i)
+		require.NoError(t, err)
+		require.Equal(t, i, v)
+	}
+	require.Equal(t, 4, cache.Size())
+	require.Equal(t, 2, cache.shards[0].cache.Size())
+	require.Equal(t, 2, cache.shards[1].cache.Size())
+}
+
+func TestShardedCapacityDistribution(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](10, 4)
+
+	require.Equal(t, 10, cache.Capacity())
+	capacities := make([]int, 0, 4)
+	for i := range cache.shards {
+		capacities = append(capacities, cache.shards[i].cache.Capacity())
+	}
+	require.Equal(t, []int{3, 3, 2, 2}, capacities)
+}
+
+func TestShardedAllDescendingFrequency(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](16, 4, WithHasher[int, int](func(key int) uint64 { return uint64(key) }))
+
+	for i := range 16 {
+		for range i + 1 {
+			cache.Put(i, i*10)
+		}
+	}
+
+	keys, values := collect(cache.All())
+	require.Len(t, keys, 16)
+	for i, k := range keys {
+		require.Equal(t, 15-i, k)
+		require.Equal(t, k*10, values[i])
+	}
+}
+
+func TestShardedAllStops(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded[int, int](8, 4)
+	for i := range 8 {
+		cache.Put(i, i)
+	}
+
+	count := 0
+	for range cache.All() {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	require.Equal(t, 3, count)
+}
+
+func TestShardedInvalidShardsPanics(t *testing.T) {
+	t.Parallel()
+
+	require.Panics(t, func() { NewSharded[int, int](4, 0) })
+	require.Panics(t, func() { NewSharded[int, int](4, 5) })
+}
+
+func TestShardedConcurrentAccess(t *testing.T) {
+	t.Parallel()
+
+	const (
+		goroutines = 16
+		operations = 2_000
+		capacity   = 64
+	)
+	cache := NewSharded[int, int](capacity, 8)
+
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range operations {
+				key := (g*operations + i) % (capacity * 2)
+				cache.Put(key, key)
+				if v, err := cache.Get(key); err == nil {
+					require.Equal(t, key, v)
+				}
+				_, _ = cache.GetKeyFrequency(key)
+				if i%100 == 0 {
+					for k, v := range cache.All() {
+						require.Equal(t, k, v)
+					}
+					require.LessOrEqual(t, cache.Size(), capacity)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, cache.Size(), capacity)
+}
+
+func BenchmarkShardedGetPut(b *testing.B) {
+	for _, shards := range []int{1, 4, 16, 64} {
+		b.Run(strconv.Itoa(shards), func(b *testing.B) {
+			cache := NewSharded[int, int](1024, shards)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					cache.Put(i%2048, i)
+					_, _ = cache.Get((i - 1) % 2048)
+					i++
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkSingleMutexGetPut(b *testing.B) {
+	cache := &mutexCache[int, int]{cache: New[int, int](1024)}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			cache.Put(i%2048, i)
+			_, _ = cache.Get((i - 1) % 2048)
+			i++
+		}
+	})
+}
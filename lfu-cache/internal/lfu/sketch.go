@@ -0,0 +1,95 @@
+package lfu
+
+import (
+	"math/bits"
+)
+
+const (
+	sketchDepth      = 4  // count of rows in countMinSketch
+	sketchMaxCounter = 15 // value, which counters of countMinSketch can't exceed
+	sketchResetRatio = 10 // count of increments per counted key after which counters are halved
+	sketchWidthRatio = 4  // count of counters in row per counted key
+)
+
+// countMinSketch is the probabilistic estimator of key frequencies with fixed memory.
+// Estimate never underestimates the count of increments since the last reset, but can overestimate it due to collisions.
+// Counters are periodically halved, so the sketch forgets old history.
+type countMinSketch[K comparable] struct {
+	rows       [sketchDepth][]uint8 // rows of counters, every row uses its own hash of key
+	mask       uint64               // width of rows minus one, width is power of two
+	hash       Hasher[K]            // hash of key, hashes of rows are derived from it
+	additions  int                  // count of increments since the last reset
+	resetAfter int                  // count of increments after which counters are halved
+}
+
+// Factory of countMinSketch, which is able to count frequencies of about size keys
+func newCountMinSketch[K comparable](size int, hash Hasher[K]) *countMinSketch[K] {
+	width := 1 << bits.Len(uint(max(size*sketchWidthRatio, 64)-1)) // the nearest power of two not less than size * sketchWidthRatio
+	s := &countMinSketch[K]{
+		mask:       uint64(width - 1),
+		hash:       hash,
+		resetAfter: max(size, 1) * sketchResetRatio,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// Function spreads bits of hash, so that weak hashers given by user don't make all rows collide
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// Function returns index of key's counter in the given row by double hashing
+func (s *countMinSketch[K]) index(h uint64, row int) uint64 {
+	h1, h2 := h&0xffffffff, h>>32|1
+	return (h1 + uint64(row)*h2) & s.mask
+}
+
+// Function increments counters of key and halves all counters, when resetAfter increments have been made
+func (s *countMinSketch[K]) increment(key K) {
+	h := mix(s.hash(key))
+	for row := range s.rows {
+		if counter := &s.rows[row][s.index(h, row)]; *counter < sketchMaxCounter {
+			*counter++
+		}
+	}
+	s.additions++
+	if s.additions == s.resetAfter { // if it is time to forget old history
+		s.reset()
+	}
+}
+
+// Function returns estimated count of increments of key
+func (s *countMinSketch[K]) estimate(key K) uint8 {
+	h := mix(s.hash(key))
+	estimate := uint8(sketchMaxCounter)
+	for row := range s.rows {
+		estimate = min(estimate, s.rows[row][s.index(h, row)])
+	}
+	return estimate
+}
+
+// Function halves all counters
+func (s *countMinSketch[K]) reset() {
+	for row := range s.rows {
+		for i := range s.rows[row] {
+			s.rows[row][i] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// Function sets all counters to zero
+func (s *countMinSketch[K]) clear() {
+	for row := range s.rows {
+		clear(s.rows[row])
+	}
+	s.additions = 0
+}
@@ -0,0 +1,196 @@
+package lfu
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidSnapshot is returned by Restore, when the snapshot is broken or has unsupported version
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// Version of the format of snapshots, it is written in every snapshot
+const snapshotVersion = 1
+
+// Encoder writes values to the stream
+type Encoder interface {
+	Encode(v any) error
+}
+
+// Decoder reads values written by Encoder from the stream
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Codec defines the format of snapshots
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// GobCodec writes snapshots in gob format, it is used by default.
+// Keys and values of interface types must be registered by gob.Register.
+var GobCodec Codec = gobCodec{}
+
+// JSONCodec writes snapshots in JSON format, keys and values must be marshalable by encoding/json
+var JSONCodec Codec = jsonCodec{}
+
+type gobCodec struct{}
+
+func (gobCodec) NewEncoder(w io.Writer) Encoder {
+	return gob.NewEncoder(w)
+}
+
+func (gobCodec) NewDecoder(r io.Reader) Decoder {
+	return gob.NewDecoder(r)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (jsonCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+// Structure, which is written to the stream by Snapshot. Fields are exported, so that codecs can access them
+type snapshotData[K comparable, V any] struct {
+	Version int
+	Entries []snapshotEntry[K, V] // keys in order of All
+}
+
+// Structure, which represents one key of the snapshot
+type snapshotEntry[K comparable, V any] struct {
+	Key       K
+	Value     V
+	Frequency int
+	ExpiresAt int64 `json:",omitempty"` // time of expiration in unix nanoseconds, zero means that key never expires
+}
+
+// Snapshot writes all alive keys with their values, frequencies and times of expiration to w
+// in order of All using the codec of the cache.
+//
+// O(capacity)
+func (l *cacheImpl[K, V]) Snapshot(w io.Writer) error {
+	data := snapshotData[K, V]{Version: snapshotVersion, Entries: make([]snapshotEntry[K, V], 0, l.size)}
+	for entry := range l.allWithFrequency() {
+		data.Entries = append(data.Entries, snapshotEntry[K, V]{entry.key, entry.val, entry.frequency, entry.expiresAt})
+	}
+	return encodeSnapshot(l.codec, w, data)
+}
+
+// Restore replaces contents of the cache by keys read from r, which has been written by Snapshot.
+// After restoring, All yields keys in the same order as before Snapshot. Keys, which have expired since Snapshot,
+// are skipped. If the snapshot doesn't fit in the cache, the least frequently used keys are dropped.
+// If the snapshot is broken, Restore returns error and doesn't change the cache.
+//
+// O(capacity)
+func (l *cacheImpl[K, V]) Restore(r io.Reader) error {
+	data, err := decodeSnapshot[K, V](l.codec, r)
+	if err != nil {
+		return err
+	}
+	l.Clear()
+	l.restoreEntries(data.Entries)
+	return nil
+}
+
+// Function encodes snapshot by the codec
+func encodeSnapshot[K comparable, V any](codec Codec, w io.Writer, data snapshotData[K, V]) error {
+	if err := codec.NewEncoder(w).Encode(data); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	return nil
+}
+
+// Function decodes snapshot by the codec and checks that keys are unique and go in descending order of frequency
+func decodeSnapshot[K comparable, V any](codec Codec, r io.Reader) (snapshotData[K, V], error) {
+	var data snapshotData[K, V]
+	if err := codec.NewDecoder(r).Decode(&data); err != nil {
+		return data, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if data.Version != snapshotVersion {
+		return data, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, data.Version)
+	}
+
+	seen := make(map[K]struct{}, len(data.Entries))
+	for i, entry := range data.Entries {
+		if entry.Frequency < 1 || (i > 0 && entry.Frequency > data.Entries[i-1].Frequency) {
+			return data, fmt.Errorf("%w: wrong frequency %d of key %v", ErrInvalidSnapshot, entry.Frequency, entry.Key)
+		}
+		if _, ok := seen[entry.Key]; ok {
+			return data, fmt.Errorf("%w: duplicate key %v", ErrInvalidSnapshot, entry.Key)
+		}
+		seen[entry.Key] = struct{}{}
+	}
+	return data, nil
+}
+
+// Function appends checked entries to the end of the cache: to the least frequency class or to the new class after it.
+// Entries, which don't fit in the cache or have expired, are skipped
+func (l *cacheImpl[K, V]) restoreEntries(entries []snapshotEntry[K, V]) {
+	classes := l.frequencyClasses
+	now := l.now().UnixNano()
+	for _, entry := range entries {
+		if l.size == l.capacity { // if the cache is filled, the rest keys are the least frequently used ones
+			return
+		}
+		if entry.ExpiresAt != 0 && entry.ExpiresAt <= now { // if key has expired since Snapshot
+			continue
+		}
+		var cost int64
+		if l.maxCost > 0 { // if cost is limited, skips keys, which don't fit
+			if cost = max(l.costOf(entry.Key, entry.Value), 0); l.cost+cost > l.maxCost {
+				continue
+			}
+		}
+
+		backNode := classes.Back()
+		if backNode.Data.lst.Size() == 0 { // if the cache is empty, its only class is reused
+			backNode.Data.frequency = entry.Frequency
+		} else if backNode.Data.frequency != entry.Frequency {
+			backNode = l.pushClass(entry.Frequency, nil)
+		}
+		l.keyToElements[entry.Key] = l.pushKeyBack(backNode.Data.lst, valOfKey[K, V]{entry.Key, entry.Value, backNode, entry.ExpiresAt, cost})
+		l.size++
+		l.cost += cost
+	}
+}
+
+// Snapshot writes keys of all shards to w in the same format as cacheImpl.Snapshot, keys are ordered like in All.
+func (c *shardedCache[K, V]) Snapshot(w io.Writer) error {
+	data := snapshotData[K, V]{Version: snapshotVersion}
+	for entry := range c.allWithFrequency() {
+		data.Entries = append(data.Entries, snapshotEntry[K, V]{entry.key, entry.val, entry.frequency, entry.expiresAt})
+	}
+	return encodeSnapshot(c.codec, w, data)
+}
+
+// Restore replaces contents of all shards by keys read from r. Keys are distributed between shards by hash,
+// so the snapshot can be restored into the cache with another count of shards. Order of keys is kept inside every shard.
+// If the snapshot is broken, Restore returns error and doesn't change the cache.
+func (c *shardedCache[K, V]) Restore(r io.Reader) error {
+	data, err := decodeSnapshot[K, V](c.codec, r)
+	if err != nil {
+		return err
+	}
+	parts := make([][]snapshotEntry[K, V], len(c.shards))
+	for _, entry := range data.Entries { // order of keys is kept inside every part
+		i := c.shardIndex(entry.Key)
+		parts[i] = append(parts[i], entry)
+	}
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.failures = nil
+		s.forgetLoads()
+		s.cache.Clear()
+		s.cache.restoreEntries(parts[i])
+		s.mu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package lfu
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func filledCache(opts ...Option[string, int]) *cacheImpl[string, int] {
+	cache := NewWithOptions(10, opts...)
+	for i, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		for range i%3 + 1 {
+			cache.Put(key, i)
+		}
+	}
+	return cache
+}
+
+func TestSnapshotRestoreKeepsOrder(t *testing.T) {
+	t.Parallel()
+
+	for _, codec := range []Codec{GobCodec, JSONCodec} {
+		cache := filledCache(WithCodec[string, int](codec))
+		keys, values := collect(cache.All())
+
+		var buf bytes.Buffer
+		require.NoError(t, cache.Snapshot(&buf))
+
+		restored := NewWithOptions(10, WithCodec[string, int](codec))
+		restored.Put("stale", 1)
+		require.NoError(t, restored.Restore(&buf))
+
+		restoredKeys, restoredValues := collect(restored.All())
+		require.Equal(t, keys, restoredKeys)
+		require.Equal(t, values, restoredValues)
+		require.Equal(t, cache.Size(), restored.Size())
+		for _, k := range keys {
+			expected, err := cache.GetKeyFrequency(k)
+			require.NoError(t, err)
+			actual, err := restored.GetKeyFrequency(k)
+			require.NoError(t, err)
+			require.Equal(t, expected, actual)
+		}
+
+		_, err := restored.Get("stale")
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	}
+}
+
+func TestRestoredCacheEvictsInSameOrder(t *testing.T) {
+	t.Parallel()
+
+	cache := filledCache()
+	var buf bytes.Buffer
+	require.NoError(t, cache.Snapshot(&buf))
+	restored := NewWithOptions[string, int](6)
+	require.NoError(t, restored.Restore(&buf))
+
+	cache = filledCache()
+	cache.capacity = 6
+	for _, c := range []*cacheImpl[string, int]{cache, restored} {
+		c.Put("x", 0)
+		c.Put("y", 0)
+	}
+
+	expected, _ := collect(cache.All())
+	actual, _ := collect(restored.All())
+	require.Equal(t, expected, actual)
+}
+
+func TestRestoreIntoSmallerCache(t *testing.T) {
+	t.Parallel()
+
+	cache := filledCache()
+	var buf bytes.Buffer
+	require.NoError(t, cache.Snapshot(&buf))
+
+	restored := NewWithOptions[string, int](3)
+	require.NoError(t, restored.Restore(&buf))
+
+	keys, _ := collect(restored.All())
+	all, _ := collect(cache.All())
+	require.Equal(t, all[:3], keys)
+	require.Equal(t, 3, restored.Size())
+}
+
+func TestSnapshotSkipsExpiredKeys(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(5, withClock[int, int](clock.Now))
+	cache.PutWithTTL(1, 1, time.Second)
+	cache.PutWithTTL(2, 2, time.Minute)
+	cache.Put(3, 3)
+	clock.Advance(time.Second)
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.Snapshot(&buf))
+
+	restored := NewWithOptions(5, withClock[int, int](clock.Now))
+	require.NoError(t, restored.Restore(&buf))
+
+	keys, _ := collect(restored.All())
+	require.Equal(t, []int{3, 2}, keys)
+
+	clock.Advance(time.Minute)
+	_, err := restored.Get(2)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestRestoreInvalidSnapshot(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"garbage":        `{`,
+		"version":        `{"Version": 2, "Entries": []}`,
+		"order":          `{"Version": 1, "Entries": [{"Key": 1, "Value": 1, "Frequency": 1}, {"Key": 2, "Value": 2, "Frequency": 2}]}`,
+		"zero frequency": `{"Version": 1, "Entries": [{"Key": 1, "Value": 1, "Frequency": 0}]}`,
+		"duplicate key":  `{"Version": 1, "Entries": [{"Key": 1, "Value": 1, "Frequency": 2}, {"Key": 1, "Value": 2, "Frequency": 1}]}`,
+		"wrong key type": `{"Version": 1, "Entries": [{"Key": "a", "Value": 1, "Frequency": 1}]}`,
+	}
+
+	for name, snapshot := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := NewWithOptions(3, WithCodec[int, int](JSONCodec))
+			cache.Put(42, 42)
+
+			require.ErrorIs(t, cache.Restore(strings.NewReader(snapshot)), ErrInvalidSnapshot)
+
+			v, err := cache.Get(42)
+			require.NoError(t, err)
+			require.Equal(t, 42, v)
+		})
+	}
+}
+
+func TestShardedSnapshotRestore(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded(16, 4, identityHasher())
+	for i := range 16 {
+		for range i%4 + 1 {
+			cache.Put(i, i*10)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, cache.Snapshot(&buf))
+
+	restored := NewSharded(16, 2, identityHasher())
+	require.NoError(t, restored.Restore(&buf))
+
+	require.Equal(t, cache.Size(), restored.Size())
+	for i := range 16 {
+		expected, err := cache.GetKeyFrequency(i)
+		require.NoError(t, err)
+		actual, err := restored.GetKeyFrequency(i)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	}
+
+	keys, _ := collect(restored.All())
+	frequencies := make([]int, 0, len(keys))
+	for _, k := range keys {
+		freq, err := restored.GetKeyFrequency(k)
+		require.NoError(t, err)
+		frequencies = append(frequencies, freq)
+	}
+	require.IsNonIncreasing(t, frequencies)
+}
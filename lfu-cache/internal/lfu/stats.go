@@ -0,0 +1,118 @@
+package lfu
+
+import (
+	"sync/atomic"
+)
+
+// Stats is the snapshot of statistics of the cache
+type Stats struct {
+	Hits        uint64 // count of Get calls, which have found the key
+	Misses      uint64 // count of Get calls, which haven't found the key
+	Puts        uint64 // count of Put calls, including updates of present keys
+	Evictions   uint64 // count of keys evicted to make place for new ones
+	Expirations uint64 // count of keys removed, because their time to live has passed
+
+	Size               int         // count of keys in the cache
+	FrequencyClasses   int         // count of different frequencies of keys in the cache
+	FrequencyHistogram map[int]int // map from frequency to count of keys with this frequency
+}
+
+// HitRatio returns part of Get calls, which have found the key, or zero, if there were no Get calls
+func (s Stats) HitRatio() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+// Function adds statistics of other to s, it is used to combine statistics of parts of the cache
+func (s *Stats) merge(other Stats) {
+	s.Hits += other.Hits
+	s.Misses += other.Misses
+	s.Puts += other.Puts
+	s.Evictions += other.Evictions
+	s.Expirations += other.Expirations
+	s.Size += other.Size
+	if s.FrequencyHistogram == nil {
+		s.FrequencyHistogram = make(map[int]int, len(other.FrequencyHistogram))
+	}
+	for freq, count := range other.FrequencyHistogram {
+		s.FrequencyHistogram[freq] += count
+	}
+	s.FrequencyClasses = len(s.FrequencyHistogram)
+}
+
+// Auxiliary structure with counters of the cache. Counters are atomic, so they can be read while the cache is used
+type statsCounters struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	puts        atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+// Function counts removing of the key for the given reason
+func (c *statsCounters) countEviction(reason EvictReason) {
+	switch reason {
+	case EvictReasonCapacity:
+		c.evictions.Add(1)
+	case EvictReasonExpired:
+		c.expirations.Add(1)
+	default:
+	}
+}
+
+// Function returns Stats with values of counters
+func (c *statsCounters) snapshot() Stats {
+	return Stats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Puts:        c.puts.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+	}
+}
+
+// Stats works like Cache.Stats.
+func (l *cacheImpl[K, V]) Stats() Stats {
+	stats := l.stats.snapshot()
+	stats.Size = l.size
+	stats.FrequencyHistogram = make(map[int]int, l.frequencyClasses.Size())
+	for class := range l.frequencyClasses.All() { // size of class is count of keys with its frequency
+		if size := class.lst.Size(); size > 0 {
+			stats.FrequencyHistogram[class.frequency] += size
+		}
+	}
+	stats.FrequencyClasses = len(stats.FrequencyHistogram)
+	return stats
+}
+
+// Stats sums statistics of all shards.
+//
+// O(shards + frequency classes)
+func (c *shardedCache[K, V]) Stats() Stats {
+	var stats Stats
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		stats.merge(s.cache.Stats())
+		s.mu.Unlock()
+	}
+	return stats
+}
+
+// Stats works like Cache.Stats, keys of the window are counted with their frequencies in the window.
+// Candidates rejected by admission are counted as evictions.
+func (c *tinyLFUCache[K, V]) Stats() Stats {
+	stats := c.stats.snapshot()
+	main := c.main.Stats()
+	stats.Evictions += main.Evictions
+	stats.Expirations += main.Expirations
+	stats.Size = c.Size()
+	stats.FrequencyHistogram = main.FrequencyHistogram
+	for entry := range c.window.All() {
+		stats.FrequencyHistogram[entry.frequency]++
+	}
+	stats.FrequencyClasses = len(stats.FrequencyHistogram)
+	return stats
+}
@@ -0,0 +1,140 @@
+package lfu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(3, withClock[int, int](clock.Now))
+
+	cache.Put(1, 1)
+	cache.Put(2, 2)
+	cache.PutWithTTL(3, 3, time.Second)
+	cache.Put(1, 10)
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(2)
+	_, _ = cache.Get(42)
+	cache.Put(4, 4) // evicts 3
+
+	clock.Advance(time.Second)
+	cache.Delete(2)
+
+	stats := cache.Stats()
+	require.Equal(t, Stats{
+		Hits:               2,
+		Misses:             1,
+		Puts:               5,
+		Evictions:          1,
+		Size:               2,
+		FrequencyClasses:   2,
+		FrequencyHistogram: map[int]int{3: 1, 1: 1},
+	}, stats)
+	require.InDelta(t, 2./3, stats.HitRatio(), 1e-9)
+}
+
+func TestStatsExpirations(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(3, withClock[int, int](clock.Now))
+
+	cache.PutWithTTL(1, 1, time.Second)
+	cache.PutWithTTL(2, 2, time.Second)
+	clock.Advance(time.Second)
+
+	_, _ = cache.Get(1)
+	cache.DeleteExpired()
+
+	stats := cache.Stats()
+	require.Equal(t, uint64(2), stats.Expirations)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, 0, stats.FrequencyClasses)
+	require.Empty(t, stats.FrequencyHistogram)
+}
+
+func TestStatsClear(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(3, withClock[int, int](clock.Now)) // without callback
+
+	cache.PutWithTTL(1, 10, time.Second)
+	cache.Put(2, 20)
+	clock.Advance(time.Second)
+	cache.Clear()
+
+	stats := cache.Stats()
+	require.EqualValues(t, 1, stats.Expirations) // expired key removed by Clear is counted like by DeleteExpired
+	require.Zero(t, stats.Evictions)
+	require.Zero(t, stats.Size)
+}
+
+func TestStatsHitRatioWithoutGets(t *testing.T) {
+	t.Parallel()
+
+	require.Zero(t, New[int, int]().Stats().HitRatio())
+}
+
+func TestShardedStats(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded(4, 2, WithHasher[int, int](func(key int) uint64 { return uint64(key) }))
+
+	for i := range 6 {
+		cache.Put(i, i)
+	}
+	_, _ = cache.Get(4)
+	_, _ = cache.Get(0)
+
+	stats := cache.Stats()
+	require.Equal(t, uint64(1), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, uint64(6), stats.Puts)
+	require.Equal(t, uint64(2), stats.Evictions)
+	require.Equal(t, 4, stats.Size)
+	require.Equal(t, map[int]int{1: 3, 2: 1}, stats.FrequencyHistogram)
+	require.Equal(t, 2, stats.FrequencyClasses)
+}
+
+func TestTinyLFUStats(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTinyLFU(10, identityHasher())
+
+	for i := range 10 {
+		cache.Put(i, i)
+		_, _ = cache.Get(i)
+	}
+	_, _ = cache.Get(9) // 9 is in the window
+	_, _ = cache.Get(100)
+	cache.Put(100, 100) // 9 is admitted to the main segment instead of 0, which was used less often
+
+	stats := cache.Stats()
+	require.Equal(t, uint64(11), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, uint64(11), stats.Puts)
+	require.Equal(t, uint64(1), stats.Evictions)
+	require.Equal(t, 10, stats.Size)
+	// keys enter the main segment with frequencies counted by the sketch, 100 is in the window
+	require.Equal(t, map[int]int{1: 1, 2: 8, 3: 1}, stats.FrequencyHistogram)
+}
+
+func BenchmarkStats(b *testing.B) {
+	cache := New[int, int](10_000)
+	for i := range 10_000 {
+		for range i % 100 {
+			cache.Put(i, i)
+		}
+	}
+	b.ResetTimer()
+
+	for range b.N {
+		_ = cache.Stats()
+	}
+}
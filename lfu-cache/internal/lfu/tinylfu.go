@@ -0,0 +1,218 @@
+package lfu
+
+import (
+	"iter"
+	"lfucache/pkg/linkedlist"
+	"slices"
+)
+
+// Part of the capacity given to the window of tinyLFUCache in percents
+const windowPercent = 1
+
+// tinyLFUCache represents W-TinyLFU cache implementation.
+// New keys are put in the small LRU window. Keys evicted from the window become candidates for the main segment,
+// which is cacheImpl. When the main segment is filled, the candidate is admitted only if countMinSketch
+// estimates its frequency higher than frequency of the key, which the main segment would evict.
+// So the keys, which are used once, can't wash out the keys, which are used often.
+type tinyLFUCache[K comparable, V any] struct {
+	window     linkedlist.LinkedList[windowEntry[K, V]]  // LRU list of the newest keys, the most recently used key is the first
+	windowKeys map[K]*linkedlist.Node[windowEntry[K, V]] // Map from key to node of window
+	windowCap  int                                       // capacity of window
+	main       *cacheImpl[K, V]                          // main segment with LFU eviction
+	sketch     *countMinSketch[K]                        // estimator of key frequencies for admission
+	onEvict    OnEvictFunc[K, V]                         // callback, which is called when the value leaves the cache, may be nil
+	capacity   int                                       // total capacity of window and main segment
+	stats      statsCounters                             // counters of calls to the whole cache and rejections of candidates
+}
+
+// Structure that is contained by the node of window
+type windowEntry[K comparable, V any] struct {
+	key       K
+	val       V
+	frequency int // count of Get and Put of the key since it has been put into the window
+}
+
+// NewTinyLFU initializes W-TinyLFU cache with the given capacity, one percent of which (at least one key) is given to the window.
+// Options are applied to the main segment, WithHasher sets hash of countMinSketch, WithOnEvict is applied to the whole cache.
+// Keys in the window don't expire and aren't counted in the cost, so WithDefaultTTL and WithMaxCost
+// affect only keys admitted to the main segment.
+// NewTinyLFU panics if capacity is not positive.
+func NewTinyLFU[K comparable, V any](capacity int, opts ...Option[K, V]) *tinyLFUCache[K, V] {
+	if capacity <= 0 { // if capacity is incorrect, NewTinyLFU panics
+		panic("The capacity must be greater than zero")
+	}
+	o := newOptions(opts)
+	hash := o.hasher
+	if hash == nil { // if hasher wasn't given
+		hash = defaultHasher[K]()
+	}
+	windowCap := max(capacity*windowPercent/100, 1)
+
+	return &tinyLFUCache[K, V]{
+		window:     linkedlist.NewLinkedList[windowEntry[K, V]](),
+		windowKeys: make(map[K]*linkedlist.Node[windowEntry[K, V]], windowCap),
+		windowCap:  windowCap,
+		main:       NewWithOptions(capacity-windowCap, opts...),
+		sketch:     newCountMinSketch(capacity, hash),
+		onEvict:    o.onEvict,
+		capacity:   capacity,
+	}
+}
+
+func (c *tinyLFUCache[K, V]) Get(key K) (V, error) {
+	c.sketch.increment(key)
+	if node, ok := c.windowKeys[key]; ok { // if key is in the window, it becomes the most recently used one
+		node.Data.frequency++
+		c.window.MoveToFront(node, c.window)
+		c.stats.hits.Add(1)
+		return node.Data.val, nil
+	}
+	val, err := c.main.Get(key)
+	if err != nil {
+		c.stats.misses.Add(1)
+	} else {
+		c.stats.hits.Add(1)
+	}
+	return val, err
+}
+
+func (c *tinyLFUCache[K, V]) Put(key K, value V) {
+	c.stats.puts.Add(1)
+	c.sketch.increment(key)
+	if node, ok := c.windowKeys[key]; ok { // if key is in the window, updates it there
+		oldValue := node.Data.val
+		node.Data.val = value
+		node.Data.frequency++
+		c.window.MoveToFront(node, c.window)
+		if c.onEvict != nil {
+			c.onEvict(key, oldValue, EvictReasonReplaced)
+		}
+		return
+	}
+	if c.main.lookup(key) != nil { // if key is in the main segment, updates it there
+		c.main.Put(key, value)
+		return
+	}
+
+	c.windowKeys[key] = c.window.PushFront(windowEntry[K, V]{key, value, 1})
+	if c.window.Size() > c.windowCap { // if window is overfilled, its least recently used key tries to get into the main segment
+		candidate := c.window.PopBack().Data
+		delete(c.windowKeys, candidate.key)
+		c.admit(candidate)
+	}
+}
+
+// Function puts candidate evicted from the window into the main segment, if the main segment has free place
+// or candidate is estimated to be used more often than the victim of the main segment. Otherwise, candidate is dropped
+func (c *tinyLFUCache[K, V]) admit(candidate windowEntry[K, V]) {
+	main := c.main
+	if main.Size() < main.Capacity() { // if there is free place, candidate is admitted without competition
+		c.promote(candidate)
+		return
+	}
+	if victim, ok := main.victim(); ok && c.sketch.estimate(candidate.key) > c.sketch.estimate(victim) {
+		c.promote(candidate) // main segment evicts victim by itself
+		return
+	}
+	c.stats.evictions.Add(1)
+	if c.onEvict != nil { // candidate loses and leaves the cache
+		c.onEvict(candidate.key, candidate.val, EvictReasonCapacity)
+	}
+}
+
+// Function puts candidate into the main segment with the frequency estimated by the sketch, so accesses counted
+// while the key was in the window aren't lost and the key isn't the first one to be evicted from the main segment.
+// The estimate doesn't exceed sketchMaxCounter, so it takes O(1)
+func (c *tinyLFUCache[K, V]) promote(candidate windowEntry[K, V]) {
+	c.main.Put(candidate.key, candidate.val)
+	node := c.main.lookup(candidate.key)
+	if node == nil { // if the value doesn't fit by cost
+		return
+	}
+	for range int(c.sketch.estimate(candidate.key)) - 1 { // Put has given the frequency 1
+		c.main.increaseFreqOfKey(node)
+	}
+}
+
+// All works like Cache.All. Keys of the window are merged with keys of the main segment by frequency,
+// keys of the window are listed first among keys with the same frequency.
+//
+// O(capacity)
+func (c *tinyLFUCache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for entry := range c.AllWithFrequency() {
+			if !yield(entry.Key, entry.Value) {
+				return
+			}
+		}
+	}
+}
+
+// AllWithFrequency works like Cache.AllWithFrequency, see tinyLFUCache.All for order of keys.
+func (c *tinyLFUCache[K, V]) AllWithFrequency() iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		windowEntries := slices.Collect(c.window.All())
+		slices.SortStableFunc(windowEntries, func(a, b windowEntry[K, V]) int { // window is small, so sorting is cheap
+			return b.frequency - a.frequency
+		})
+
+		for entry := range c.main.allWithFrequency() { // merges two sequences sorted in descending order of frequency
+			for len(windowEntries) > 0 && windowEntries[0].frequency >= entry.frequency {
+				if !yield(Entry[K, V]{windowEntries[0].key, windowEntries[0].val, windowEntries[0].frequency}) {
+					return
+				}
+				windowEntries = windowEntries[1:]
+			}
+			if !yield(Entry[K, V]{entry.key, entry.val, entry.frequency}) {
+				return
+			}
+		}
+		for _, entry := range windowEntries { // yields the rest of the window
+			if !yield(Entry[K, V]{entry.key, entry.val, entry.frequency}) {
+				return
+			}
+		}
+	}
+}
+
+func (c *tinyLFUCache[K, V]) Size() int {
+	return c.window.Size() + c.main.Size()
+}
+
+func (c *tinyLFUCache[K, V]) Capacity() int {
+	return c.capacity
+}
+
+func (c *tinyLFUCache[K, V]) GetKeyFrequency(key K) (int, error) {
+	if node, ok := c.windowKeys[key]; ok {
+		return node.Data.frequency, nil
+	}
+	return c.main.GetKeyFrequency(key)
+}
+
+func (c *tinyLFUCache[K, V]) Delete(key K) bool {
+	node, ok := c.windowKeys[key]
+	if !ok { // if key isn't in the window, it may be in the main segment
+		return c.main.Delete(key)
+	}
+	c.window.Remove(node)
+	delete(c.windowKeys, key)
+	if c.onEvict != nil {
+		c.onEvict(key, node.Data.val, EvictReasonDeleted)
+	}
+	return true
+}
+
+// Clear works like Cache.Clear, history of frequencies is forgotten too.
+func (c *tinyLFUCache[K, V]) Clear() {
+	old := c.window
+	c.window = linkedlist.NewLinkedList[windowEntry[K, V]]()
+	c.windowKeys = make(map[K]*linkedlist.Node[windowEntry[K, V]], c.windowCap)
+	c.sketch.clear()
+	if c.onEvict != nil {
+		for entry := range old.All() {
+			c.onEvict(entry.key, entry.val, EvictReasonDeleted)
+		}
+	}
+	c.main.Clear()
+}
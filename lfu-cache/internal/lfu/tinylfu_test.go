@@ -0,0 +1,254 @@
+package lfu
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// must compile
+var _ Cache[int, int] = (*tinyLFUCache[int, int])(nil)
+
+// identity hash makes tests deterministic, sketch mixes its bits by itself
+func identityHasher() Option[int, int] {
+	return WithHasher[int, int](func(key int) uint64 { return uint64(key) })
+}
+
+func TestTinyLFUWindowAndAdmission(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTinyLFU(10, identityHasher())
+	require.Equal(t, 1, cache.windowCap)
+	require.Equal(t, 9, cache.main.Capacity())
+
+	for i := range 10 {
+		cache.Put(i, i*10)
+	}
+	require.Equal(t, 10, cache.Size())
+	require.Equal(t, 9, cache.main.Size())
+
+	for i := range 10 {
+		v, err := cache.Get(i)
+		require.NoError(t, err)
+		require.Equal(t, i*10, v)
+	}
+}
+
+func TestTinyLFURejectsRareCandidate(t *testing.T) {
+	t.Parallel()
+
+	recorder := new(evictRecorder)
+	cache := NewTinyLFU(10, WithOnEvict(recorder.onEvict), identityHasher())
+
+	for i := range 10 {
+		cache.Put(i, i)
+		_, _ = cache.Get(i)
+	}
+
+	cache.Put(100, 100) // pushes 9 out of the window, 9 beats the victim only if it was used more often
+	cache.Put(101, 101) // pushes 100 out of the window, 100 was used once, so it loses
+
+	_, err := cache.Get(100)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.Contains(t, recorder.events, evictEvent{100, 100, EvictReasonCapacity})
+	require.Equal(t, 10, cache.Size())
+}
+
+func TestTinyLFUAdmitsFrequentCandidate(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTinyLFU(10, identityHasher())
+
+	for i := range 10 {
+		cache.Put(i, i)
+	}
+
+	cache.Put(100, 100)
+	for range 5 {
+		_, _ = cache.Get(100)
+	}
+	cache.Put(101, 101) // pushes 100 out of the window, it was used more often than the victim of the main segment
+
+	v, err := cache.Get(100)
+	require.NoError(t, err)
+	require.Equal(t, 100, v)
+	require.Equal(t, 10, cache.Size())
+}
+
+func TestTinyLFUKeepsFrequencyOfAdmittedKey(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTinyLFU(10, identityHasher())
+
+	cache.Put(100, 100)
+	for range 3 {
+		_, _ = cache.Get(100)
+	}
+	cache.Put(101, 101) // pushes 100 out of the window into the free main segment
+
+	frequency, err := cache.main.GetKeyFrequency(100)
+	require.NoError(t, err)
+	require.Equal(t, 4, frequency) // Put and three Gets counted by the sketch
+
+	for i := range 9 { // fills the main segment with keys used once, so 100 isn't evicted first
+		cache.Put(i, i)
+	}
+	v, err := cache.Get(100)
+	require.NoError(t, err)
+	require.Equal(t, 100, v)
+}
+
+func TestTinyLFUScanResistance(t *testing.T) {
+	t.Parallel()
+
+	const hotKeys = 50
+	cache := NewTinyLFU(100, identityHasher())
+
+	for range 10 {
+		for k := range hotKeys {
+			cache.Put(k, k)
+		}
+	}
+	for k := 1000; k < 3000; k++ {
+		cache.Put(k, k)
+	}
+
+	for k := range hotKeys - 1 { // the last hot key was in the window, so it has entered the main segment with frequency 1
+		freq, err := cache.GetKeyFrequency(k)
+		require.NoError(t, err)
+		require.Equal(t, 10, freq)
+	}
+}
+
+func TestTinyLFUAllDeleteClear(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTinyLFU(10, identityHasher())
+
+	for i := range 10 {
+		for range i + 1 {
+			cache.Put(i, i)
+		}
+	}
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}, keys)
+
+	freq, err := cache.GetKeyFrequency(9)
+	require.NoError(t, err)
+	require.Equal(t, 10, freq)
+
+	require.True(t, cache.Delete(9))
+	require.True(t, cache.Delete(0))
+	require.False(t, cache.Delete(0))
+	require.Equal(t, 8, cache.Size())
+
+	cache.Clear()
+	require.Equal(t, 0, cache.Size())
+	keys, _ = collect(cache.All())
+	require.Empty(t, keys)
+}
+
+func TestTinyLFUCapacityOne(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTinyLFU[int, int](1)
+
+	cache.Put(1, 1)
+	cache.Put(2, 2)
+
+	_, err := cache.Get(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	v, err := cache.Get(2)
+	require.NoError(t, err)
+	require.Equal(t, 2, v)
+}
+
+func TestCountMinSketch(t *testing.T) {
+	t.Parallel()
+
+	sketch := newCountMinSketch(100, defaultHasher[int]())
+
+	for range 5 {
+		sketch.increment(1)
+	}
+	for range 100 {
+		sketch.increment(2)
+	}
+
+	require.GreaterOrEqual(t, sketch.estimate(1), uint8(5))
+	require.Equal(t, uint8(sketchMaxCounter), sketch.estimate(2))
+
+	for i := range sketch.resetAfter {
+		sketch.increment(1000 + i)
+	}
+	require.Less(t, sketch.estimate(2), uint8(sketchMaxCounter))
+
+	sketch.clear()
+	require.Equal(t, uint8(0), sketch.estimate(2))
+}
+
+// zipfTrace returns the trace of keys with Zipf distribution.
+// Every phase the popular keys are shifted, so the cache must adapt to the new hot set.
+func zipfTrace(length, keys, phases int, s float64) []int {
+	r := rand.New(rand.NewPCG(42, 42))
+	zipf := rand.NewZipf(r, s, 1, uint64(keys-1))
+	trace := make([]int, length)
+	for i := range trace {
+		phase := i * phases / length
+		trace[i] = int(zipf.Uint64()) + phase*keys
+	}
+	return trace
+}
+
+// hitRatio replays the trace against the cache, missed keys are put into the cache
+func hitRatio(cache Cache[int, int], trace []int) float64 {
+	hits := 0
+	for _, k := range trace {
+		if _, err := cache.Get(k); err == nil {
+			hits++
+		} else {
+			cache.Put(k, k)
+		}
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+func BenchmarkHitRatioZipf(b *testing.B) {
+	const (
+		capacity = 1_000
+		keys     = 100_000
+		length   = 1_000_000
+	)
+	traces := []struct {
+		name   string
+		phases int
+		s      float64
+	}{
+		{"static-s1.01", 1, 1.01},
+		{"static-s1.2", 1, 1.2},
+		{"shifting-s1.01", 10, 1.01},
+		{"shifting-s1.2", 10, 1.2},
+	}
+	caches := []struct {
+		name   string
+		create func() Cache[int, int]
+	}{
+		{"lfu", func() Cache[int, int] { return New[int, int](capacity) }},
+		{"tinylfu", func() Cache[int, int] { return NewTinyLFU[int, int](capacity) }},
+	}
+
+	for _, tr := range traces {
+		trace := zipfTrace(length, keys, tr.phases, tr.s)
+		for _, c := range caches {
+			b.Run(tr.name+"/"+c.name, func(b *testing.B) {
+				var ratio float64
+				for range b.N {
+					ratio = hitRatio(c.create(), trace)
+				}
+				b.ReportMetric(ratio*100, "hit%")
+			})
+		}
+	}
+}
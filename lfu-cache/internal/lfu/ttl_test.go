@@ -0,0 +1,225 @@
+package lfu
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is the manually moved source of time
+type fakeClock struct {
+	cur atomic.Int64
+}
+
+func newFakeClock() *fakeClock {
+	c := new(fakeClock)
+	c.cur.Store(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	return time.Unix(0, c.cur.Load())
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.cur.Add(int64(d))
+}
+
+func TestDefaultTTLExpiresOnGet(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(3, WithDefaultTTL[int, int](time.Minute), withClock[int, int](clock.Now))
+
+	cache.Put(1, 10)
+	clock.Advance(30 * time.Second)
+
+	v, err := cache.Get(1)
+	require.NoError(t, err)
+	require.Equal(t, 10, v)
+
+	clock.Advance(30 * time.Second)
+
+	_, err = cache.Get(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = cache.GetKeyFrequency(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.Equal(t, 0, cache.Size())
+}
+
+func TestPutWithTTLOverridesDefault(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(3, WithDefaultTTL[int, int](time.Minute), withClock[int, int](clock.Now))
+
+	cache.PutWithTTL(1, 10, time.Second)
+	cache.PutWithTTL(2, 20, 0)
+	cache.Put(3, 30)
+
+	clock.Advance(2 * time.Second)
+
+	_, err := cache.GetKeyFrequency(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	clock.Advance(time.Hour)
+
+	_, err = cache.Get(3)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	v, err := cache.Get(2)
+	require.NoError(t, err)
+	require.Equal(t, 20, v)
+}
+
+func TestExpiredKeysAreSkippedByAll(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(5, withClock[int, int](clock.Now))
+
+	cache.Put(1, 10)
+	cache.PutWithTTL(2, 20, time.Second)
+	cache.Put(3, 30)
+	cache.PutWithTTL(4, 40, time.Second)
+	_, _ = cache.Get(4)
+
+	clock.Advance(time.Second)
+
+	keys, values := collect(cache.All())
+	require.Equal(t, []int{3, 1}, keys)
+	require.Equal(t, []int{30, 10}, values)
+}
+
+func TestPutRevivesExpiredKeyWithFrequencyOne(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(2, withClock[int, int](clock.Now))
+
+	cache.PutWithTTL(1, 10, time.Second)
+	_, _ = cache.Get(1)
+	_, _ = cache.Get(1)
+
+	clock.Advance(time.Second)
+	cache.Put(1, 11)
+
+	frequency, err := cache.GetKeyFrequency(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, frequency)
+	require.Equal(t, 1, cache.Size())
+
+	clock.Advance(time.Hour)
+	v, err := cache.Get(1)
+	require.NoError(t, err)
+	require.Equal(t, 11, v)
+}
+
+func TestExpirationKeepsEvictionOrder(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(3, withClock[int, int](clock.Now))
+
+	cache.Put(1, 10)
+	cache.PutWithTTL(2, 20, time.Second)
+	cache.Put(3, 30)
+	_, _ = cache.Get(2)
+	_, _ = cache.Get(3)
+
+	clock.Advance(time.Second)
+
+	_, err := cache.Get(2)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	cache.Put(4, 40)
+	cache.Put(5, 50) // evicts 1 as the least frequently used key
+
+	_, err = cache.Get(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{3, 5, 4}, keys)
+}
+
+func TestDeleteExpired(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(6, withClock[int, int](clock.Now))
+
+	for i := range 6 {
+		if i%2 == 0 {
+			cache.PutWithTTL(i, i, time.Second)
+		} else {
+			cache.Put(i, i)
+		}
+		for range i {
+			_, _ = cache.Get(i)
+		}
+	}
+
+	clock.Advance(time.Second)
+
+	require.Equal(t, 3, cache.DeleteExpired())
+	require.Equal(t, 3, cache.Size())
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{5, 3, 1}, keys)
+	require.Equal(t, 3, cache.frequencyClasses.Size())
+	require.Equal(t, 0, cache.DeleteExpired())
+}
+
+func TestDeleteExpiredKeepsLastClass(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewWithOptions(2, withClock[int, int](clock.Now))
+
+	cache.PutWithTTL(1, 10, time.Second)
+	_, _ = cache.Get(1)
+
+	clock.Advance(time.Second)
+
+	require.Equal(t, 1, cache.DeleteExpired())
+	require.Equal(t, 0, cache.Size())
+
+	cache.Put(2, 20)
+	cache.Put(3, 30)
+	cache.Put(4, 40)
+
+	frequency, err := cache.GetKeyFrequency(4)
+	require.NoError(t, err)
+	require.Equal(t, 1, frequency)
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{4, 3}, keys)
+}
+
+func TestShardedJanitor(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	cache := NewSharded(8, 4, withClock[int, int](clock.Now))
+
+	for i := range 4 {
+		cache.PutWithTTL(i, i, time.Second)
+	}
+	cache.Put(4, 4)
+
+	stop := cache.StartJanitor(time.Millisecond)
+	defer stop()
+
+	clock.Advance(time.Second)
+
+	require.Eventually(t, func() bool {
+		return cache.Size() == 1
+	}, time.Second, time.Millisecond)
+
+	stop()
+	stop()
+
+	keys, _ := collect(cache.All())
+	require.Equal(t, []int{4}, keys)
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"lfucache/internal/lfu"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Upper bounds of buckets of the histogram of key frequencies
+var frequencyBuckets = prometheus.ExponentialBuckets(1, 2, 17)
+
+// StatsSource is the cache, which can report its statistics
+type StatsSource interface {
+	Stats() lfu.Stats
+}
+
+// Collector exposes statistics of the cache as Prometheus metrics.
+// Statistics are read from the cache on every scrape, so Collector adds no overhead to Get and Put
+type Collector struct {
+	source StatsSource
+
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	puts        *prometheus.Desc
+	evictions   *prometheus.Desc
+	expirations *prometheus.Desc
+	size        *prometheus.Desc
+	classes     *prometheus.Desc
+	frequencies *prometheus.Desc
+}
+
+// NewCollector creates Collector of statistics of source. Metrics are named namespace_lfu_cache_*
+// and have label "cache" with the given name, so several caches can be registered in one registry.
+func NewCollector(namespace, name string, source StatsSource) *Collector {
+	labels := prometheus.Labels{"cache": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "lfu_cache", metric), help, nil, labels)
+	}
+
+	return &Collector{
+		source:      source,
+		hits:        desc("hits_total", "Count of Get calls, which have found the key."),
+		misses:      desc("misses_total", "Count of Get calls, which haven't found the key."),
+		puts:        desc("puts_total", "Count of Put calls."),
+		evictions:   desc("evictions_total", "Count of keys evicted to make place for new ones."),
+		expirations: desc("expirations_total", "Count of keys removed after their time to live."),
+		size:        desc("size", "Count of keys in the cache."),
+		classes:     desc("frequency_classes", "Count of different frequencies of keys in the cache."),
+		frequencies: desc("key_frequency", "Distribution of frequencies of keys in the cache."),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.hits, c.misses, c.puts, c.evictions, c.expirations, c.size, c.classes, c.frequencies} {
+		ch <- d
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.source.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.puts, prometheus.CounterValue, float64(stats.Puts))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(stats.Expirations))
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(stats.Size))
+	ch <- prometheus.MustNewConstMetric(c.classes, prometheus.GaugeValue, float64(stats.FrequencyClasses))
+
+	count, sum, buckets := frequencyHistogram(stats.FrequencyHistogram)
+	ch <- prometheus.MustNewConstHistogram(c.frequencies, count, sum, buckets)
+}
+
+// Function converts histogram of frequencies to cumulative buckets of Prometheus histogram
+func frequencyHistogram(histogram map[int]int) (count uint64, sum float64, buckets map[float64]uint64) {
+	frequencies := make([]int, 0, len(histogram))
+	for freq := range histogram {
+		frequencies = append(frequencies, freq)
+	}
+	slices.Sort(frequencies)
+
+	buckets = make(map[float64]uint64, len(frequencyBuckets))
+	i := 0
+	for _, bound := range frequencyBuckets { // frequencies are sorted, so every frequency is added once
+		for ; i < len(frequencies) && float64(frequencies[i]) <= bound; i++ {
+			count += uint64(histogram[frequencies[i]])
+			sum += float64(frequencies[i] * histogram[frequencies[i]])
+		}
+		buckets[bound] = count
+	}
+	for ; i < len(frequencies); i++ { // frequencies above the last bound are counted only in +Inf bucket
+		count += uint64(histogram[frequencies[i]])
+		sum += float64(frequencies[i] * histogram[frequencies[i]])
+	}
+	return count, sum, buckets
+}
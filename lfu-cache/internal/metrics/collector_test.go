@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"lfucache/internal/lfu"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type staticSource lfu.Stats
+
+func (s staticSource) Stats() lfu.Stats {
+	return lfu.Stats(s)
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	collector := NewCollector("library", "books", staticSource{
+		Hits:               7,
+		Misses:             3,
+		Puts:               5,
+		Evictions:          2,
+		Size:               3,
+		FrequencyClasses:   2,
+		FrequencyHistogram: map[int]int{1: 2, 5: 1},
+	})
+
+	expected := `
+# HELP library_lfu_cache_hits_total Count of Get calls, which have found the key.
+# TYPE library_lfu_cache_hits_total counter
+library_lfu_cache_hits_total{cache="books"} 7
+# HELP library_lfu_cache_evictions_total Count of keys evicted to make place for new ones.
+# TYPE library_lfu_cache_evictions_total counter
+library_lfu_cache_evictions_total{cache="books"} 2
+# HELP library_lfu_cache_size Count of keys in the cache.
+# TYPE library_lfu_cache_size gauge
+library_lfu_cache_size{cache="books"} 3
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"library_lfu_cache_hits_total", "library_lfu_cache_evictions_total", "library_lfu_cache_size"))
+	require.Equal(t, 8, testutil.CollectAndCount(collector))
+}
+
+func TestCollectorWithCache(t *testing.T) {
+	t.Parallel()
+
+	cache := lfu.New[int, int](2)
+	collector := NewCollector("", "test", cache)
+
+	cache.Put(1, 1)
+	_, _ = cache.Get(1)
+
+	expected := `
+# HELP lfu_cache_hits_total Count of Get calls, which have found the key.
+# TYPE lfu_cache_hits_total counter
+lfu_cache_hits_total{cache="test"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "lfu_cache_hits_total"))
+}
+
+func TestFrequencyHistogram(t *testing.T) {
+	t.Parallel()
+
+	count, sum, buckets := frequencyHistogram(map[int]int{1: 2, 3: 1, 1 << 20: 1})
+
+	require.Equal(t, uint64(4), count)
+	require.InDelta(t, float64(2+3+1<<20), sum, 0)
+	require.Equal(t, uint64(2), buckets[1])
+	require.Equal(t, uint64(2), buckets[2])
+	require.Equal(t, uint64(3), buckets[4])
+	require.Equal(t, uint64(3), buckets[65536])
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lfucache/internal/lfu"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Default values of Config fields
+const (
+	DefaultMaxValueSize    = 1 << 20
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// Store is the thread-safe cache served by Server, the cache created by lfu.NewSharded satisfies it
+type Store interface {
+	Get(key string) ([]byte, error)
+	PutWithTTL(key string, value []byte, ttl time.Duration)
+	Delete(key string) bool
+	Stats() lfu.Stats
+	Snapshot(w io.Writer) error
+	Restore(r io.Reader) error
+}
+
+// Config configures Server, zero values of fields mean defaults
+type Config struct {
+	SnapshotPath    string        // file, which the cache is restored from and written to on shutdown, empty means no snapshots
+	MaxValueSize    int64         // limit of size of value in bytes, larger values are rejected
+	ShutdownTimeout time.Duration // time given to running requests to finish on shutdown
+}
+
+// Server serves the cache over HTTP with JSON errors and statistics:
+//
+//	GET    /keys/{key}          returns the value as the body or 404, if there is no key
+//	PUT    /keys/{key}?ttl=10s  puts the body as the value, ttl is optional
+//	DELETE /keys/{key}          deletes the key or returns 404, if there is no key
+//	GET    /stats               returns lfu.Stats of the cache
+type Server struct {
+	store  Store
+	config Config
+	mux    *http.ServeMux
+}
+
+// Structure, which is written as the body of responses with errors
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New creates Server of the given store
+func New(store Store, config Config) *Server {
+	if config.MaxValueSize <= 0 {
+		config.MaxValueSize = DefaultMaxValueSize
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	s := &Server{store: store, config: config, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /keys/{key}", s.handleGet)
+	s.mux.HandleFunc("PUT /keys/{key}", s.handlePut)
+	s.mux.HandleFunc("DELETE /keys/{key}", s.handleDelete)
+	s.mux.HandleFunc("GET /stats", s.handleStats)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Serve restores the cache from the snapshot, if it exists, and serves requests from ln until ctx is done.
+// Then it stops accepting connections, waits for running requests at most ShutdownTimeout and writes the snapshot.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	if err := s.LoadSnapshot(); err != nil {
+		_ = ln.Close()
+		return err
+	}
+
+	srv := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr: // if server has failed by itself
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.config.ShutdownTimeout)
+	defer cancel()
+	shutdownErr := srv.Shutdown(shutdownCtx)
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		shutdownErr = errors.Join(shutdownErr, err)
+	}
+	return errors.Join(shutdownErr, s.SaveSnapshot()) // snapshot is written even if some requests haven't finished
+}
+
+// LoadSnapshot restores the cache from SnapshotPath. It does nothing, if the path isn't set or the file doesn't exist.
+func (s *Server) LoadSnapshot() error {
+	if s.config.SnapshotPath == "" {
+		return nil
+	}
+	f, err := os.Open(s.config.SnapshotPath)
+	if errors.Is(err, os.ErrNotExist) { // if there is nothing to restore, the cache starts empty
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open snapshot: %w", err)
+	}
+	defer f.Close()
+	if err := s.store.Restore(f); err != nil {
+		return fmt.Errorf("restore snapshot: %w", err)
+	}
+	return nil
+}
+
+// SaveSnapshot writes the cache to SnapshotPath. The snapshot is written to the temporary file, which then replaces
+// the old one, so the old snapshot stays whole, if writing fails. It does nothing, if the path isn't set.
+func (s *Server) SaveSnapshot() error {
+	if s.config.SnapshotPath == "" {
+		return nil
+	}
+	f, err := os.CreateTemp(filepath.Dir(s.config.SnapshotPath), filepath.Base(s.config.SnapshotPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	if err := errors.Join(s.store.Snapshot(f), f.Close()); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(f.Name(), s.config.SnapshotPath); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("replace snapshot: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	value, err := s.store.Get(r.PathValue("key"))
+	if errors.Is(err, lfu.ErrKeyNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(value)
+}
+
+func (s *Server) handlePut(w http.ResponseWriter, r *http.Request) {
+	var ttl time.Duration
+	if raw := r.URL.Query().Get("ttl"); raw != "" {
+		var err error
+		if ttl, err = time.ParseDuration(raw); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid ttl: %w", err))
+			return
+		}
+	}
+	value, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.config.MaxValueSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.store.PutWithTTL(r.PathValue("key"), value, ttl)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if !s.store.Delete(r.PathValue("key")) {
+		writeError(w, http.StatusNotFound, lfu.ErrKeyNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.store.Stats())
+}
+
+// Function writes error as JSON body with the given status
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{err.Error()})
+}
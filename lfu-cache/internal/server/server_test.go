@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"lfucache/internal/lfu"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newStore() Store {
+	return lfu.NewSharded[string, []byte](100, 4)
+}
+
+func do(t *testing.T, method, u string, body string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, u, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestServerKeys(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(New(newStore(), Config{MaxValueSize: 8}))
+	defer ts.Close()
+	key := ts.URL + "/keys/" + url.PathEscape("a/b c")
+
+	status, body := do(t, http.MethodGet, key, "")
+	require.Equal(t, http.StatusNotFound, status)
+	require.JSONEq(t, `{"error": "key not found"}`, body)
+
+	status, _ = do(t, http.MethodPut, key, "value")
+	require.Equal(t, http.StatusNoContent, status)
+	status, body = do(t, http.MethodGet, key, "")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "value", body)
+
+	status, _ = do(t, http.MethodPut, key+"?ttl=soon", "value")
+	require.Equal(t, http.StatusBadRequest, status)
+	status, _ = do(t, http.MethodPut, key, "too large value")
+	require.Equal(t, http.StatusRequestEntityTooLarge, status)
+
+	status, _ = do(t, http.MethodDelete, key, "")
+	require.Equal(t, http.StatusNoContent, status)
+	status, _ = do(t, http.MethodDelete, key, "")
+	require.Equal(t, http.StatusNotFound, status)
+
+	status, body = do(t, http.MethodGet, ts.URL+"/stats", "")
+	require.Equal(t, http.StatusOK, status)
+	var stats lfu.Stats
+	require.NoError(t, json.Unmarshal([]byte(body), &stats))
+	require.Equal(t, uint64(1), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, uint64(1), stats.Puts)
+}
+
+func TestServeShutdownWritesSnapshot(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	serve := func(store Store) (string, context.CancelFunc, chan error) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- New(store, Config{SnapshotPath: path}).Serve(ctx, ln)
+		}()
+		return "http://" + ln.Addr().String(), cancel, done
+	}
+
+	base, cancel, done := serve(newStore())
+	status, _ := do(t, http.MethodPut, base+"/keys/1", "one")
+	require.Equal(t, http.StatusNoContent, status)
+	cancel()
+	require.NoError(t, <-done)
+
+	store := newStore()
+	base, cancel, done = serve(store)
+	status, body := do(t, http.MethodGet, base+"/keys/1", "")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "one", body)
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestLoadSnapshotErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, New(newStore(), Config{}).LoadSnapshot())
+	require.NoError(t, New(newStore(), Config{SnapshotPath: filepath.Join(dir, "missing")}).LoadSnapshot())
+
+	broken := filepath.Join(dir, "broken")
+	srv := New(newStore(), Config{SnapshotPath: broken})
+	require.NoError(t, srv.SaveSnapshot())
+	require.NoError(t, os.WriteFile(broken, bytes.Repeat([]byte{0xff}, 16), 0o600))
+	require.ErrorIs(t, srv.LoadSnapshot(), lfu.ErrInvalidSnapshot)
+}
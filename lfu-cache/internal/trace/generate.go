@@ -0,0 +1,66 @@
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+)
+
+// ErrInvalidParameter is returned by generators, when the trace can't be generated with the given parameters
+var ErrInvalidParameter = errors.New("invalid parameter of trace")
+
+// Zipf returns trace of the given length, where keys from zero to keys-1 are accessed with Zipf distribution:
+// the key of rank i is accessed about 1/i^s times as often as the most popular one. s must be greater than one.
+// Trace is determined by seed.
+func Zipf(length, keys int, s float64, seed uint64) ([]uint64, error) {
+	if err := checkSize(length, keys); err != nil {
+		return nil, err
+	}
+	if !(s > 1) { // NaN isn't greater than one either
+		return nil, fmt.Errorf("%w: skew %v must be greater than one", ErrInvalidParameter, s)
+	}
+	r := rand.New(rand.NewPCG(seed, seed))
+	zipf := rand.NewZipf(r, s, 1, uint64(keys-1))
+	trace := make([]uint64, length)
+	for i := range trace {
+		trace[i] = zipf.Uint64()
+	}
+	return trace, nil
+}
+
+// Scan returns trace of the given length, where every access is to the new key starting from start.
+// Such trace has no hits at all, it is used to check that scans don't wash out popular keys.
+func Scan(length int, start uint64) ([]uint64, error) {
+	if err := checkSize(length, 1); err != nil {
+		return nil, err
+	}
+	trace := make([]uint64, length)
+	for i := range trace {
+		trace[i] = start + uint64(i)
+	}
+	return trace, nil
+}
+
+// Loop returns trace of the given length, where keys from zero to keys-1 are accessed in cycle.
+// LRU and LFU have no hits on loop longer than their capacity, while caches with admission keep part of the loop.
+func Loop(length, keys int) ([]uint64, error) {
+	if err := checkSize(length, keys); err != nil {
+		return nil, err
+	}
+	trace := make([]uint64, length)
+	for i := range trace {
+		trace[i] = uint64(i % keys)
+	}
+	return trace, nil
+}
+
+// Function checks that length of the trace isn't negative and there is at least one key
+func checkSize(length, keys int) error {
+	if length < 0 {
+		return fmt.Errorf("%w: length %d is negative", ErrInvalidParameter, length)
+	}
+	if keys <= 0 {
+		return fmt.Errorf("%w: count of keys %d must be positive", ErrInvalidParameter, keys)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidTrace is returned by readers, when the line of the trace can't be parsed
+var ErrInvalidTrace = errors.New("invalid trace")
+
+// maxARCCount limits count of blocks in one line of ARC trace, so the broken line can't take all memory.
+// Requests of real traces are much shorter
+const maxARCCount = 1 << 20
+
+// ReadCSV reads keys from the given column of CSV access log, columns are numbered from zero.
+// If header is true, the first record is skipped.
+func ReadCSV(r io.Reader, column int, header bool) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // access logs may have optional columns
+	reader.ReuseRecord = true
+	var keys []string
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return keys, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTrace, err)
+		}
+		if header && line == 1 {
+			continue
+		}
+		if column < 0 || column >= len(record) {
+			return nil, fmt.Errorf("%w: line %d has no column %d", ErrInvalidTrace, line, column)
+		}
+		keys = append(keys, strings.Clone(record[column])) // record is reused, so the key is copied
+	}
+}
+
+// ReadARC reads trace in format of ARC paper: every line is "start count ignored request", it means access
+// to count blocks from start. Every accessed block becomes separate key, so count must not exceed 2^20.
+func ReadARC(r io.Reader) ([]uint64, error) {
+	var keys []uint64
+	err := readLines(r, func(line int, fields []string) error {
+		if len(fields) < 2 {
+			return fmt.Errorf("%w: line %d has %d fields instead of at least 2", ErrInvalidTrace, line, len(fields))
+		}
+		start, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %w", ErrInvalidTrace, line, err)
+		}
+		count, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %w", ErrInvalidTrace, line, err)
+		}
+		if count > maxARCCount || start+count < start { // if blocks would take too much memory or overflow uint64
+			return fmt.Errorf("%w: line %d has count %d out of range", ErrInvalidTrace, line, count)
+		}
+		for block := range count {
+			keys = append(keys, start+block)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// ReadLIRS reads trace in format of LIRS paper: every line contains number of accessed block.
+// Lines with "*", which mark ends of parts of some traces, are skipped.
+func ReadLIRS(r io.Reader) ([]uint64, error) {
+	var keys []uint64
+	err := readLines(r, func(line int, fields []string) error {
+		if fields[0] == "*" {
+			return nil
+		}
+		block, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %w", ErrInvalidTrace, line, err)
+		}
+		keys = append(keys, block)
+		return nil
+	})
+	return keys, err
+}
+
+// Function calls parse with fields of every non-empty line of r, lines are numbered from one
+func readLines(r io.Reader, parse func(line int, fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 { // skips empty lines
+			continue
+		}
+		if err := parse(line, fields); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
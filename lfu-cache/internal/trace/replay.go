@@ -0,0 +1,68 @@
+package trace
+
+import (
+	"lfucache/internal/lfu"
+	"runtime"
+	"time"
+)
+
+// Result describes replay of the trace against the cache
+type Result struct {
+	Accesses   int           // count of keys in the trace
+	Hits       int           // count of accesses, which have found the key in the cache
+	Duration   time.Duration // time spent on Get and Put calls
+	Allocs     uint64        // count of heap allocations made during replay
+	AllocBytes uint64        // count of bytes allocated during replay
+}
+
+// HitRatio returns part of accesses, which have found the key, or zero, if the trace is empty
+func (r Result) HitRatio() float64 {
+	if r.Accesses == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Accesses)
+}
+
+// Throughput returns count of accesses per second
+func (r Result) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Accesses) / r.Duration.Seconds()
+}
+
+// AllocsPerAccess returns average count of heap allocations per access
+func (r Result) AllocsPerAccess() float64 {
+	if r.Accesses == 0 {
+		return 0
+	}
+	return float64(r.Allocs) / float64(r.Accesses)
+}
+
+// Replay drives the cache by the trace: every key is read by Get, missed keys are put with zero value.
+// Allocations are counted for the whole process, so other goroutines must not allocate during Replay.
+func Replay[K comparable, V any](cache lfu.Cache[K, V], trace []K) Result {
+	var zeroVal V
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	hits := 0
+	for _, key := range trace {
+		if _, err := cache.Get(key); err == nil {
+			hits++
+		} else {
+			cache.Put(key, zeroVal)
+		}
+	}
+
+	duration := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return Result{
+		Accesses:   len(trace),
+		Hits:       hits,
+		Duration:   duration,
+		Allocs:     after.Mallocs - before.Mallocs,
+		AllocBytes: after.TotalAlloc - before.TotalAlloc,
+	}
+}
@@ -0,0 +1,153 @@
+package trace
+
+import (
+	"lfucache/internal/lfu"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCSV(t *testing.T) {
+	t.Parallel()
+
+	log := "time,key,size\n1,a,10\n2,b\n3,\"a\",30\n"
+	keys, err := ReadCSV(strings.NewReader(log), 1, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "a"}, keys)
+
+	_, err = ReadCSV(strings.NewReader(log), 2, true)
+	require.ErrorIs(t, err, ErrInvalidTrace)
+	_, err = ReadCSV(strings.NewReader("a,\"b\n"), 0, false)
+	require.ErrorIs(t, err, ErrInvalidTrace)
+}
+
+func TestReadARC(t *testing.T) {
+	t.Parallel()
+
+	keys, err := ReadARC(strings.NewReader("10 3 0 1\n\n42 1 0 2\n"))
+	require.NoError(t, err)
+	require.Equal(t, []uint64{10, 11, 12, 42}, keys)
+
+	_, err = ReadARC(strings.NewReader("10\n"))
+	require.ErrorIs(t, err, ErrInvalidTrace)
+	require.ErrorContains(t, err, "line 1 has 1 fields instead of at least 2")
+	keys, err = ReadARC(strings.NewReader("10 2\n")) // ignored fields may be missing
+	require.NoError(t, err)
+	require.Equal(t, []uint64{10, 11}, keys)
+	_, err = ReadARC(strings.NewReader("10 x 0 1\n"))
+	require.ErrorIs(t, err, ErrInvalidTrace)
+	_, err = ReadARC(strings.NewReader("10 18446744073709551615 0 1\n")) // isn't expanded into keys
+	require.ErrorIs(t, err, ErrInvalidTrace)
+	_, err = ReadARC(strings.NewReader("18446744073709551615 2 0 1\n"))
+	require.ErrorIs(t, err, ErrInvalidTrace)
+}
+
+func TestReadLIRS(t *testing.T) {
+	t.Parallel()
+
+	keys, err := ReadLIRS(strings.NewReader("5\n7\n*\n5\n"))
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5, 7, 5}, keys)
+
+	_, err = ReadLIRS(strings.NewReader("5\n-1\n"))
+	require.ErrorIs(t, err, ErrInvalidTrace)
+}
+
+func TestGenerators(t *testing.T) {
+	t.Parallel()
+
+	zipf, err := Zipf(10_000, 100, 1.2, 1)
+	require.NoError(t, err)
+	again, err := Zipf(10_000, 100, 1.2, 1)
+	require.NoError(t, err)
+	require.Equal(t, zipf, again)
+	counts := make(map[uint64]int)
+	for _, key := range zipf {
+		require.Less(t, key, uint64(100))
+		counts[key]++
+	}
+	require.Greater(t, counts[0], counts[10])
+
+	single, err := Zipf(3, 1, 1.2, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 0, 0}, single)
+
+	scan, err := Scan(3, 5)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5, 6, 7}, scan)
+	loop, err := Loop(5, 3)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 1, 2, 0, 1}, loop)
+}
+
+func TestGeneratorsInvalidParameters(t *testing.T) {
+	t.Parallel()
+
+	for name, generate := range map[string]func() ([]uint64, error){
+		"zipf skew one":      func() ([]uint64, error) { return Zipf(10, 100, 1, 1) },
+		"zipf skew NaN":      func() ([]uint64, error) { return Zipf(10, 100, math.NaN(), 1) },
+		"zipf no keys":       func() ([]uint64, error) { return Zipf(10, 0, 1.2, 1) },
+		"zipf negative size": func() ([]uint64, error) { return Zipf(-1, 100, 1.2, 1) },
+		"scan negative size": func() ([]uint64, error) { return Scan(-1, 0) },
+		"loop no keys":       func() ([]uint64, error) { return Loop(10, 0) },
+		"loop negative size": func() ([]uint64, error) { return Loop(-1, 3) },
+	} {
+		_, err := generate()
+		require.ErrorIs(t, err, ErrInvalidParameter, name)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	loop, err := Loop(30, 3)
+	require.NoError(t, err)
+	res := Replay(lfu.New[uint64, struct{}](3), loop)
+	require.Equal(t, 30, res.Accesses)
+	require.Equal(t, 27, res.Hits)
+	require.InDelta(t, 0.9, res.HitRatio(), 1e-9)
+	require.Positive(t, res.Throughput())
+
+	scan, err := Scan(30, 0)
+	require.NoError(t, err)
+	res = Replay(lfu.New[uint64, struct{}](3), scan)
+	require.Zero(t, res.Hits)
+	require.Zero(t, Result{}.HitRatio())
+	require.Zero(t, Result{}.Throughput())
+	require.Zero(t, Result{}.AllocsPerAccess())
+}
+
+func BenchmarkReplayZipf(b *testing.B) {
+	const capacity = 1_000
+	trace, err := Zipf(100_000, 10_000, 1.1, 42)
+	require.NoError(b, err)
+	caches := []struct {
+		name   string
+		create func() lfu.Cache[uint64, struct{}]
+	}{
+		{"lfu", func() lfu.Cache[uint64, struct{}] { return lfu.New[uint64, struct{}](capacity) }},
+		{"lru", func() lfu.Cache[uint64, struct{}] {
+			return lfu.NewCache(capacity, lfu.WithPolicy[uint64, struct{}](lfu.PolicyLRU))
+		}},
+		{"arc", func() lfu.Cache[uint64, struct{}] {
+			return lfu.NewCache(capacity, lfu.WithPolicy[uint64, struct{}](lfu.PolicyARC))
+		}},
+		{"lfu-da", func() lfu.Cache[uint64, struct{}] {
+			return lfu.NewCache(capacity, lfu.WithPolicy[uint64, struct{}](lfu.PolicyLFUDA))
+		}},
+		{"tinylfu", func() lfu.Cache[uint64, struct{}] { return lfu.NewTinyLFU[uint64, struct{}](capacity) }},
+	}
+
+	for _, c := range caches {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var res Result
+			for range b.N {
+				res = Replay(c.create(), trace)
+			}
+			b.ReportMetric(res.HitRatio()*100, "hit%")
+		})
+	}
+}
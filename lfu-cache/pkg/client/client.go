@@ -0,0 +1,162 @@
+// Package client implements the client of the distributed cache, which consists of nodes started by cmd/lfu-node.
+// Keys are spread between nodes by consistent hashing, so adding or removing a node moves only a small part of keys.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lfucache/internal/lfu"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNoNodes is returned by New, when no nodes are given
+var ErrNoNodes = errors.New("no nodes of the cache")
+
+// Client spreads keys between nodes served by server.Server using consistent hashing.
+// Client is safe for concurrent use
+type Client struct {
+	ring *ring
+	http *http.Client
+}
+
+// Structure, which is read from the body of responses with errors
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New creates Client of the nodes with the given base URLs, for example "http://10.0.0.1:8080".
+// If httpClient is nil, http.DefaultClient is used.
+func New(nodes []string, httpClient *http.Client) (*Client, error) {
+	if len(nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	trimmed := make([]string, len(nodes))
+	for i, node := range nodes { // "http://host/" and "http://host" are the same node
+		trimmed[i] = strings.TrimSuffix(node, "/")
+	}
+	return &Client{ring: newRing(trimmed), http: httpClient}, nil
+}
+
+// NodeFor returns base URL of the node, which owns the key.
+func (c *Client) NodeFor(key string) string {
+	return c.ring.node(key)
+}
+
+// Get returns the value of the key or lfu.ErrKeyNotFound, if the node doesn't have the key.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.keyURL(key, 0), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Put puts the value of the key. Non-positive ttl means that the key never expires.
+func (c *Client) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	resp, err := c.do(ctx, http.MethodPut, c.keyURL(key, ttl), value)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkStatus(resp, http.StatusNoContent)
+}
+
+// Delete deletes the key and reports whether the key was present.
+func (c *Client) Delete(ctx context.Context, key string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodDelete, c.keyURL(key, 0), nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, http.StatusNoContent); errors.Is(err, lfu.ErrKeyNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Stats returns statistics of every node by its base URL. Nodes are asked one by one,
+// the first failed request stops Stats and its error is returned.
+func (c *Client) Stats(ctx context.Context) (map[string]lfu.Stats, error) {
+	stats := make(map[string]lfu.Stats)
+	for _, p := range c.ring.points {
+		if _, ok := stats[p.node]; ok { // if node has been already asked
+			continue
+		}
+		nodeStats, err := c.nodeStats(ctx, p.node)
+		if err != nil {
+			return nil, err
+		}
+		stats[p.node] = nodeStats
+	}
+	return stats, nil
+}
+
+// Function requests statistics of the node
+func (c *Client) nodeStats(ctx context.Context, node string) (lfu.Stats, error) {
+	var stats lfu.Stats
+	resp, err := c.do(ctx, http.MethodGet, node+"/stats", nil)
+	if err != nil {
+		return stats, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return stats, err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return stats, fmt.Errorf("decode stats of %s: %w", node, err)
+	}
+	return stats, nil
+}
+
+// Function returns URL of the key on its node, ttl is added, if it is positive
+func (c *Client) keyURL(key string, ttl time.Duration) string {
+	u := c.ring.node(key) + "/keys/" + url.PathEscape(key)
+	if ttl > 0 {
+		u += "?ttl=" + url.QueryEscape(ttl.String())
+	}
+	return u
+}
+
+// Function sends request with the given body, body may be nil
+func (c *Client) do(ctx context.Context, method, u string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	return c.http.Do(req)
+}
+
+// Function returns nil, if response has the expected status, lfu.ErrKeyNotFound for 404 and error with message of server otherwise
+func checkStatus(resp *http.Response, expected int) error {
+	if resp.StatusCode == expected {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return lfu.ErrKeyNotFound
+	}
+	var body errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		return fmt.Errorf("unexpected status %s of %s", resp.Status, resp.Request.URL)
+	}
+	return fmt.Errorf("status %s of %s: %s", resp.Status, resp.Request.URL, body.Error)
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"lfucache/internal/lfu"
+	"lfucache/internal/server"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// startNodes starts in-process servers and returns their caches by base URL
+func startNodes(t *testing.T, n int) ([]string, map[string]server.Store) {
+	t.Helper()
+
+	urls := make([]string, 0, n)
+	stores := make(map[string]server.Store, n)
+	for range n {
+		store := lfu.NewSharded[string, []byte](1_000, 4)
+		ts := httptest.NewServer(server.New(store, server.Config{}))
+		t.Cleanup(ts.Close)
+		urls = append(urls, ts.URL)
+		stores[ts.URL] = store
+	}
+	return urls, stores
+}
+
+func TestClientSpreadsKeys(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	urls, stores := startNodes(t, 3)
+	c, err := New(urls, nil)
+	require.NoError(t, err)
+
+	for i := range 300 {
+		key := fmt.Sprintf("key/%d", i)
+		require.NoError(t, c.Put(ctx, key, []byte(key), 0))
+	}
+	for i := range 300 {
+		key := fmt.Sprintf("key/%d", i)
+		value, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, key, string(value))
+
+		for node, store := range stores { // key lives only on its node
+			_, err := store.Get(key)
+			require.Equal(t, node == c.NodeFor(key), err == nil)
+		}
+	}
+
+	stats, err := c.Stats(ctx)
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+	total := 0
+	for _, s := range stats {
+		require.NotZero(t, s.Size) // every node has got some keys
+		total += s.Size
+	}
+	require.Equal(t, 300, total)
+}
+
+func TestClientGetPutDelete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	urls, _ := startNodes(t, 2)
+	c, err := New(urls, nil)
+	require.NoError(t, err)
+
+	_, err = c.Get(ctx, "missing")
+	require.ErrorIs(t, err, lfu.ErrKeyNotFound)
+
+	require.NoError(t, c.Put(ctx, "key", []byte("value"), time.Hour))
+	deleted, err := c.Delete(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, deleted)
+	deleted, err = c.Delete(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, deleted)
+
+	_, err = New(nil, nil)
+	require.ErrorIs(t, err, ErrNoNodes)
+}
+
+func TestClientReportsServerErrors(t *testing.T) {
+	t.Parallel()
+
+	store := lfu.NewSharded[string, []byte](10, 1)
+	ts := httptest.NewServer(server.New(store, server.Config{MaxValueSize: 1}))
+	defer ts.Close()
+
+	c, err := New([]string{ts.URL + "/"}, nil)
+	require.NoError(t, err)
+	err = c.Put(context.Background(), "key", []byte("too large"), 0)
+	require.ErrorContains(t, err, "413")
+	require.ErrorContains(t, err, "request body too large")
+}
+
+func TestRingMovesFewKeys(t *testing.T) {
+	t.Parallel()
+
+	before := newRing([]string{"a", "b", "c"})
+	after := newRing([]string{"c", "b", "a", "d"})
+	reordered := newRing([]string{"c", "a", "b"})
+
+	const keys = 10_000
+	moved := 0
+	counts := make(map[string]int)
+	for i := range keys {
+		key := fmt.Sprintf("key-%d", i)
+		require.Equal(t, before.node(key), reordered.node(key))
+		counts[before.node(key)]++
+		if owner := after.node(key); owner != before.node(key) {
+			require.Equal(t, "d", owner) // keys move only to the new node
+			moved++
+		}
+	}
+	require.InDelta(t, keys/4, moved, keys/10)
+	for _, count := range counts {
+		require.InDelta(t, keys/3, count, keys/10)
+	}
+}
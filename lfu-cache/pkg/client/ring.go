@@ -0,0 +1,66 @@
+package client
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// Count of points of every node on the ring, more points make distribution of keys more even
+const virtualNodes = 128
+
+// ring is the consistent hashing ring. Every node has virtualNodes points on the ring, the key belongs to the node
+// of the first point not less than hash of the key. When the node is added or removed, only keys of its points move.
+// Hash doesn't depend on the process, so all clients with the same nodes agree on placement of keys
+type ring struct {
+	points []point // points of all nodes sorted by hash
+}
+
+// Point of the node on the ring
+type point struct {
+	hash uint64
+	node string
+}
+
+// Factory of ring with the given nodes
+func newRing(nodes []string) *ring {
+	r := &ring{points: make([]point, 0, len(nodes)*virtualNodes)}
+	for _, node := range nodes {
+		for i := range virtualNodes {
+			r.points = append(r.points, point{hashOf(node + "#" + strconv.Itoa(i)), node})
+		}
+	}
+	slices.SortFunc(r.points, func(a, b point) int {
+		if a.hash != b.hash {
+			return cmp.Compare(a.hash, b.hash)
+		}
+		return cmp.Compare(a.node, b.node) // collisions are resolved by name, so the order doesn't depend on order of nodes
+	})
+	return r
+}
+
+// Function returns FNV-1a hash of s with bits mixed by splitmix finalizer, so that similar names get distant points
+func hashOf(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Function returns the node, which owns the key
+func (r *ring) node(key string) string {
+	h := hashOf(key)
+	i, _ := slices.BinarySearchFunc(r.points, h, func(p point, h uint64) int {
+		return cmp.Compare(p.hash, h)
+	})
+	if i == len(r.points) { // ring is closed, so after the last point goes the first one
+		i = 0
+	}
+	return r.points[i].node
+}
@@ -0,0 +1,357 @@
+// Package linkedlist implements generic doubly linked list, which moves nodes between lists without memory allocation.
+// It is intended for LRU queues, schedulers and other structures, which keep pointers to nodes and reorder them.
+package linkedlist
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// ErrCorrupted is returned by Check and is used in panics of debug lists, when invariants of the list are broken
+var ErrCorrupted = errors.New("linked list is corrupted")
+
+type LinkedList[T any] interface {
+
+	// Adds value to end of list.
+	PushBack(value T) *Node[T]
+
+	// Adds value to start of list.
+	PushFront(value T) *Node[T]
+
+	// Moves node from listNode to this list after head without memmory allocation.
+	// This list can be ListNode.
+	// If given node doesn't belong listNode, behaviour of listNode and its real list is undefined.
+	// Given node and listNode must not be nil.
+	MoveToFront(node *Node[T], listNode LinkedList[T])
+
+	// Moves node from listNode to end of this list without memory allocation.
+	// This list can be listNode.
+	// If given node doesn't belong listNode, behaviour of listNode and its real list is undefined.
+	// Given node and listNode must not be nil.
+	MoveToBack(node *Node[T], listNode LinkedList[T])
+
+	// Moves node from listNode before given node of this list without memory allocation.
+	// This list can be listNode, then node and given node must be different.
+	// If nodes don't belong their lists, behaviour of these lists is undefined.
+	// Given nodes and listNode must not be nil.
+	MoveBefore(node *Node[T], beforeNode *Node[T], listNode LinkedList[T])
+
+	// Adds value before given node and returns new node containings given value,
+	// If given node doesn't belong this list, behaviour of its real list is undefined.
+	// Given node must not be nil.
+	PushBefore(node *Node[T], value T) *Node[T]
+
+	// Adds value after given node and returns new node containing given value.
+	// If given node doesn't belong this list, behaviour of its real list is undefined.
+	// Given node must not be nil.
+	PushAfter(node *Node[T], value T) *Node[T]
+
+	// Moves all nodes of other list before given node of this list without memory allocation, other list becomes empty.
+	// If given node is nil, nodes are moved to end of this list.
+	// If given node doesn't belong this list, behaviour of its real list is undefined.
+	// Other list must not be nil and must not be this list.
+	// It takes O(1), if other list is created by this package, otherwise nodes are moved one by one.
+	InsertList(node *Node[T], other LinkedList[T])
+
+	// Removes given node from this list.
+	// If given node doesn't belong this list, behaviour of this and its real list is undefined.
+	// Given node must not be nil.
+	Remove(node *Node[T])
+
+	// Removes value from end of list and returns deleted node or nil, if list is empty.
+	PopBack() *Node[T]
+
+	// Removes value from start of list and returns deleted node or nil, if list is empty.
+	PopFront() *Node[T]
+
+	// Returns count of value in list.
+	Size() int
+
+	// Returns first node of list or nil, if list is empty.
+	Front() *Node[T]
+
+	// Returns last node of list or nil, if list is empty.
+	Back() *Node[T]
+
+	// Returns iterator, which goes from Front() to Back() of list.
+	All() iter.Seq[T]
+
+	// Returns iterator, which goes from Back() to Front() of list.
+	Backward() iter.Seq[T]
+
+	// Checks invariants of list: links of neighbouring nodes agree with each other and size is equal to count of nodes.
+	// Returns error wrapping ErrCorrupted, if any invariant is broken.
+	//
+	// O(size)
+	Check() error
+}
+
+// Node - element of LinkedList
+type Node[T any] struct {
+	prev *Node[T] // pointer to previous node
+	next *Node[T] // pointer to next node
+	Data T        // Data witch node contains
+}
+
+// Factory of nodes. Returns a node containing the specified data, with prev and next pointing to it
+func NewNode[T any](data T) *Node[T] {
+	ans := &Node[T]{Data: data}
+	ans.prev = ans
+	ans.next = ans
+	return ans
+}
+
+// Returns previous node of current node or nil if node is first in listNode
+// If node doesn't belong listNode, behaviour of real its list is undefined.
+func (curNode *Node[T]) Prev(listNode LinkedList[T]) *Node[T] {
+	if listNode.Front() != curNode { // if previous node is not head of this list
+		return curNode.prev // returns previous node
+	}
+	return nil
+}
+
+// Returns next node of current node or nil if node is last in listNode
+// If node doesn't belong listNode, behaviour of real its list is undefined.
+func (curNode *Node[T]) Next(listNode LinkedList[T]) *Node[T] {
+	if listNode.Back() != curNode { // if next node is not head of this list
+		return curNode.next // returns previous node
+	}
+	return nil
+}
+
+// Connects given nodes together so that, node1 is previous node of node2 and node2 is next node of node1
+func connectNodes[T any](node1 *Node[T], node2 *Node[T]) {
+	if node1 != nil { // checking that given pointer of node points to really node
+		node1.next = node2
+	}
+	if node2 != nil {
+		node2.prev = node1
+	}
+}
+
+// Realization of interface linkedlist. Сircular list
+type linkedListImpl[T any] struct {
+	head  *Node[T] // pointer to first technical node
+	size  int      // count of nodes in list without head
+	debug bool     // if it is set, invariants are checked on every change of list
+}
+
+// Factory of linkedlists. Returns the empty list
+func NewLinkedList[T any]() LinkedList[T] {
+	return newLinkedListImpl[T](false)
+}
+
+// Factory of linkedlists in debug mode. Debug list checks that given nodes belong to the lists they are taken from
+// and checks invariants after every change, it panics with error wrapping ErrCorrupted, if they are broken.
+// Checks take O(size) time, so debug lists are intended for tests.
+func NewDebugLinkedList[T any]() LinkedList[T] {
+	return newLinkedListImpl[T](true)
+}
+
+// Factory of linkedListImpl. Returns the empty list with initialized head
+func newLinkedListImpl[T any](debug bool) *linkedListImpl[T] {
+	var zeroVal T
+	return &linkedListImpl[T]{head: NewNode(zeroVal), debug: debug}
+}
+
+// Function panics, if list is in debug mode and given node isn't its node
+func (lst *linkedListImpl[T]) mustContain(node *Node[T]) {
+	if !lst.debug {
+		return
+	}
+	for cur := lst.head.next; cur != lst.head; cur = cur.next { // searches node from the front
+		if cur == node {
+			return
+		}
+	}
+	panic(fmt.Errorf("%w: node doesn't belong to the list", ErrCorrupted))
+}
+
+// Function panics, if list is in debug mode and its invariants are broken
+func (lst *linkedListImpl[T]) mustBeValid() {
+	if !lst.debug {
+		return
+	}
+	if err := lst.Check(); err != nil {
+		panic(err)
+	}
+}
+
+func (lst *linkedListImpl[T]) Check() error {
+	cur := lst.head
+	for i := 0; i <= lst.size; i++ { // goes size+1 steps, so that it must return to head
+		if cur.next == nil || cur.next.prev != cur {
+			return fmt.Errorf("%w: links of node %d and its next node disagree", ErrCorrupted, i)
+		}
+		cur = cur.next
+		if cur == lst.head && i != lst.size {
+			return fmt.Errorf("%w: size is %d, but list contains %d nodes", ErrCorrupted, lst.size, i)
+		}
+	}
+	if cur != lst.head {
+		return fmt.Errorf("%w: list contains more than %d nodes", ErrCorrupted, lst.size)
+	}
+	return nil
+}
+
+func (lst *linkedListImpl[T]) PushBefore(beforeNode *Node[T], value T) *Node[T] {
+	if beforeNode != lst.head {
+		lst.mustContain(beforeNode)
+	}
+	newNode := NewNode(value)
+	connectNodes(beforeNode.prev, newNode)
+	connectNodes(newNode, beforeNode)
+	lst.size++
+
+	lst.mustBeValid()
+	return newNode
+}
+
+func (lst *linkedListImpl[T]) PushAfter(afterNode *Node[T], value T) *Node[T] {
+	lst.mustContain(afterNode)
+	return lst.PushBefore(afterNode.next, value) // node after afterNode may be head, then value is pushed to end of list
+}
+
+func (lst *linkedListImpl[T]) PushBack(value T) *Node[T] {
+	return lst.PushBefore(lst.head, value) // reusing PushBefore due to the fact that the list is circular
+}
+
+func (lst *linkedListImpl[T]) PushFront(value T) *Node[T] {
+	return lst.PushBefore(lst.head.next, value) // reusing PushBefore due to the fact that the list is circular
+}
+
+func (lst *linkedListImpl[T]) MoveBefore(node *Node[T], beforeNode *Node[T], listNode LinkedList[T]) {
+	if beforeNode != lst.head {
+		lst.mustContain(beforeNode)
+	}
+	if beforeNode.prev == node { // if given node is already before beforeNode
+		return // There's nothing to do
+	}
+
+	listNode.Remove(node)               // removes node from its list
+	connectNodes(beforeNode.prev, node) // connects node with neighbours of its new place
+	connectNodes(node, beforeNode)
+	lst.size++
+
+	lst.mustBeValid()
+}
+
+func (lst *linkedListImpl[T]) MoveToFront(node *Node[T], listNode LinkedList[T]) {
+	head := lst.head
+	headNext := head.next
+	if headNext == node { // if given node is already been after head
+		return // There's nothing to do
+	}
+
+	listNode.Remove(node)    // removes node from its list
+	connectNodes(head, node) // connects node with head and node after head
+	connectNodes(node, headNext)
+	lst.size++
+
+	lst.mustBeValid()
+}
+
+func (lst *linkedListImpl[T]) MoveToBack(node *Node[T], listNode LinkedList[T]) {
+	lst.MoveBefore(node, lst.head, listNode) // reusing MoveBefore due to the fact that the list is circular
+}
+
+func (lst *linkedListImpl[T]) InsertList(beforeNode *Node[T], other LinkedList[T]) {
+	src, ok := other.(*linkedListImpl[T])
+	if ok && src == lst {
+		panic("list can't be inserted into itself")
+	}
+	if beforeNode == nil { // if nodes must be moved to end of list
+		beforeNode = lst.head
+	} else {
+		lst.mustContain(beforeNode)
+	}
+	if !ok { // if other list is implemented outside of the package, e.g. wraps the list, nodes are moved by its Remove
+		for other.Size() > 0 {
+			lst.MoveBefore(other.Front(), beforeNode, other)
+		}
+		return
+	}
+	src.mustBeValid()
+	if src.size == 0 { // if there is nothing to move
+		return
+	}
+
+	first, last := src.head.next, src.head.prev
+	connectNodes(src.head, src.head) // other list becomes empty
+	connectNodes(beforeNode.prev, first)
+	connectNodes(last, beforeNode)
+	lst.size += src.size
+	src.size = 0
+
+	lst.mustBeValid()
+}
+
+func (lst *linkedListImpl[T]) Remove(node *Node[T]) {
+	lst.mustContain(node)
+	connectNodes(node.prev, node.next) // connects the neighbours nodes of given node
+	lst.size--
+	lst.mustBeValid()
+}
+
+func (lst *linkedListImpl[T]) PopBack() *Node[T] {
+	if lst.Size() == 0 { // if there isn't node, which can be removed
+		return nil // returns nil
+	}
+	remBack := lst.Back() // takes node for removing
+	lst.Remove(remBack)
+	return remBack // returns removed node
+}
+
+func (lst *linkedListImpl[T]) PopFront() *Node[T] {
+	if lst.Size() == 0 { // if there isn't node, which can be removed
+		return nil
+	}
+	remFront := lst.Front() // takes node for removing
+	lst.Remove(remFront)
+	return remFront
+}
+
+func (lst *linkedListImpl[T]) Size() int {
+	return lst.size
+}
+
+func (lst *linkedListImpl[T]) Front() *Node[T] {
+	if lst.Size() > 0 { // if list contains any nodes
+		return lst.head.next // returns node after head
+	}
+	return nil
+}
+
+func (lst *linkedListImpl[T]) Back() *Node[T] {
+	if lst.Size() > 0 { // if list contains any nodes
+		return lst.head.prev // returns node before head (due to the circular sheet, the last node)
+	}
+	return nil
+}
+
+func (lst *linkedListImpl[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		cur := lst.head // head as starting node
+		n := lst.Size()
+		for range n { // n times
+			cur = cur.Next(lst)   // goes to next node
+			if !yield(cur.Data) { // checks that user wants next value
+				return
+			}
+		}
+	}
+}
+
+func (lst *linkedListImpl[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		cur := lst.head // head as starting node
+		n := lst.Size()
+		for range n { // n times
+			cur = cur.prev        // goes to previous node, after head it is the last node
+			if !yield(cur.Data) { // checks that user wants next value
+				return
+			}
+		}
+	}
+}
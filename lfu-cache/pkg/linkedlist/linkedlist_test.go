@@ -0,0 +1,251 @@
+package linkedlist
+
+import (
+	"slices"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/require"
+)
+
+// model is the list with the slice of its nodes in the expected order
+type model struct {
+	lst   LinkedList[int]
+	nodes []*Node[int]
+}
+
+// pick returns index of node chosen by arg or -1, if model is empty
+func (m *model) pick(arg int) int {
+	if len(m.nodes) == 0 {
+		return -1
+	}
+	return arg % len(m.nodes)
+}
+
+func (m *model) data() []int {
+	var data []int
+	for _, node := range m.nodes {
+		data = append(data, node.Data)
+	}
+	return data
+}
+
+func requireMatches(t *testing.T, m *model) {
+	t.Helper()
+
+	require.NoError(t, m.lst.Check())
+	require.Equal(t, len(m.nodes), m.lst.Size())
+
+	data := m.data()
+	require.Equal(t, data, slices.Collect(m.lst.All()))
+	slices.Reverse(data)
+	require.Equal(t, data, slices.Collect(m.lst.Backward()))
+
+	if len(m.nodes) == 0 {
+		require.Nil(t, m.lst.Front())
+		require.Nil(t, m.lst.Back())
+		return
+	}
+	require.Same(t, m.nodes[0], m.lst.Front())
+	require.Same(t, m.nodes[len(m.nodes)-1], m.lst.Back())
+	for i, node := range m.nodes {
+		if i > 0 {
+			require.Same(t, m.nodes[i-1], node.Prev(m.lst))
+		} else {
+			require.Nil(t, node.Prev(m.lst))
+		}
+		if i+1 < len(m.nodes) {
+			require.Same(t, m.nodes[i+1], node.Next(m.lst))
+		} else {
+			require.Nil(t, node.Next(m.lst))
+		}
+	}
+}
+
+// TestProperties applies random sequences of operations to two debug lists and compares them with slices
+func TestProperties(t *testing.T) {
+	t.Parallel()
+
+	property := func(ops []uint16) bool {
+		models := [2]*model{
+			{lst: NewDebugLinkedList[int]()},
+			{lst: NewDebugLinkedList[int]()},
+		}
+		value := 0
+		for _, op := range ops {
+			m, other := models[op&1], models[(op&1)^1]
+			arg := int(op >> 5)
+			value++
+
+			switch op >> 1 & 15 {
+			case 0:
+				m.nodes = append(m.nodes, m.lst.PushBack(value))
+			case 1:
+				m.nodes = slices.Insert(m.nodes, 0, m.lst.PushFront(value))
+			case 2:
+				if i := m.pick(arg); i >= 0 {
+					m.nodes = slices.Insert(m.nodes, i, m.lst.PushBefore(m.nodes[i], value))
+				}
+			case 3:
+				if i := m.pick(arg); i >= 0 {
+					m.nodes = slices.Insert(m.nodes, i+1, m.lst.PushAfter(m.nodes[i], value))
+				}
+			case 4:
+				if i := m.pick(arg); i >= 0 {
+					m.lst.Remove(m.nodes[i])
+					m.nodes = slices.Delete(m.nodes, i, i+1)
+				}
+			case 5:
+				node := m.lst.PopBack()
+				if len(m.nodes) == 0 {
+					require.Nil(t, node)
+				} else {
+					require.Same(t, m.nodes[len(m.nodes)-1], node)
+					m.nodes = m.nodes[:len(m.nodes)-1]
+				}
+			case 6:
+				node := m.lst.PopFront()
+				if len(m.nodes) == 0 {
+					require.Nil(t, node)
+				} else {
+					require.Same(t, m.nodes[0], node)
+					m.nodes = m.nodes[1:]
+				}
+			case 7, 8: // moves node of other list or of this list
+				src := other
+				if arg&1 == 0 {
+					src = m
+				}
+				if i := src.pick(arg >> 1); i >= 0 {
+					node := src.nodes[i]
+					src.nodes = slices.Delete(src.nodes, i, i+1)
+					if op>>1&15 == 7 {
+						m.lst.MoveToFront(node, src.lst)
+						m.nodes = slices.Insert(m.nodes, 0, node)
+					} else {
+						m.lst.MoveToBack(node, src.lst)
+						m.nodes = append(m.nodes, node)
+					}
+				}
+			case 9:
+				i := m.pick(arg)
+				if i < 0 || arg&1 == 0 { // inserts to the back
+					m.lst.InsertList(nil, other.lst)
+					m.nodes = append(m.nodes, other.nodes...)
+				} else {
+					m.lst.InsertList(m.nodes[i], other.lst)
+					m.nodes = slices.Insert(m.nodes, i, other.nodes...)
+				}
+				other.nodes = nil
+			case 10: // moves node of other list before node of this list
+				i, j := m.pick(arg), other.pick(arg>>3)
+				if i < 0 || j < 0 {
+					break
+				}
+				node := other.nodes[j]
+				m.lst.MoveBefore(node, m.nodes[i], other.lst)
+				other.nodes = slices.Delete(other.nodes, j, j+1)
+				m.nodes = slices.Insert(m.nodes, i, node)
+			case 11: // moves node of this list before another node of this list
+				i, j := m.pick(arg), m.pick(arg>>3)
+				if i < 0 || i == j {
+					break
+				}
+				node, before := m.nodes[j], m.nodes[i]
+				m.lst.MoveBefore(node, before, m.lst)
+				m.nodes = slices.Delete(m.nodes, j, j+1)
+				m.nodes = slices.Insert(m.nodes, slices.Index(m.nodes, before), node)
+			default:
+			}
+
+			requireMatches(t, models[0])
+			requireMatches(t, models[1])
+		}
+		return true
+	}
+
+	require.NoError(t, quick.Check(property, &quick.Config{MaxCount: 500}))
+}
+
+func TestDebugListPanicsOnForeignNode(t *testing.T) {
+	t.Parallel()
+
+	lst := NewDebugLinkedList[int]()
+	foreign := NewLinkedList[int]().PushBack(1)
+	lst.PushBack(2)
+
+	require.PanicsWithError(t, "linked list is corrupted: node doesn't belong to the list", func() { lst.Remove(foreign) })
+	require.Panics(t, func() { lst.PushAfter(foreign, 3) })
+	require.Panics(t, func() { lst.MoveToFront(foreign, lst) })
+	require.Panics(t, func() { lst.InsertList(nil, lst) })
+}
+
+func TestCheckDetectsCorruption(t *testing.T) {
+	t.Parallel()
+
+	lst := newLinkedListImpl[int](false)
+	lst.PushBack(1)
+	second := lst.PushBack(2)
+	lst.PushBack(3)
+	require.NoError(t, lst.Check())
+
+	lst.size++
+	require.ErrorIs(t, lst.Check(), ErrCorrupted)
+	lst.size -= 2
+	require.ErrorIs(t, lst.Check(), ErrCorrupted)
+	lst.size++
+
+	second.prev = second
+	require.ErrorIs(t, lst.Check(), ErrCorrupted)
+}
+
+// countingList implements LinkedList outside of linkedListImpl by wrapping it
+type countingList[T any] struct {
+	LinkedList[T]
+	removed int // count of calls of Remove
+}
+
+func (lst *countingList[T]) Remove(node *Node[T]) {
+	lst.removed++
+	lst.LinkedList.Remove(node)
+}
+
+func TestInsertWrappedList(t *testing.T) {
+	t.Parallel()
+
+	lst := NewDebugLinkedList[int]()
+	lst.PushBack(1)
+	other := &countingList[int]{LinkedList: NewDebugLinkedList[int]()}
+	other.PushBack(2)
+	other.PushBack(3)
+
+	lst.InsertList(lst.Front(), other)
+	require.Equal(t, []int{2, 3, 1}, slices.Collect(lst.All()))
+	require.Equal(t, 0, other.Size())
+	require.Equal(t, 2, other.removed) // nodes have been moved through Remove of the wrapper
+}
+
+func TestIteratorsStop(t *testing.T) {
+	t.Parallel()
+
+	lst := NewLinkedList[int]()
+	for i := range 5 {
+		lst.PushBack(i)
+	}
+
+	var forward, backward []int
+	for v := range lst.All() {
+		if v == 2 {
+			break
+		}
+		forward = append(forward, v)
+	}
+	for v := range lst.Backward() {
+		if v == 2 {
+			break
+		}
+		backward = append(backward, v)
+	}
+	require.Equal(t, []int{0, 1}, forward)
+	require.Equal(t, []int{4, 3}, backward)
+}